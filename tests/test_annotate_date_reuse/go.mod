@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_annotate_date_reuse
+
+require github.com/pkg/profile v1.2.1
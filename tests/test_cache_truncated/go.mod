@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_cache_truncated
+
+require github.com/pkg/errors v0.9.1
@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/pkg/old";
+    fetch = {
+      type = "git";
+      url = "https://github.com/pkg/old";
+      rev = "v0.1.0";
+      sha256 = "0hrdh4qjdaw3xzg5r4ybff2l3la7j5ls4h5ggv4g6jzzkncpnqgi";
+    };
+  }
+  {
+    goPackagePath = "github.com/pkg/profile";
+    fetch = {
+      type = "git";
+      url = "https://github.com/pkg/profile";
+      rev = "v1.2.0";
+      sha256 = "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr";
+    };
+  }
+]
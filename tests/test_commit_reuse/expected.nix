@@ -0,0 +1,13 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/pkg/profile";
+    fetch = {
+      type = "git";
+      url = "https://github.com/pkg/profile";
+      rev = "v1.2.1";
+      commit = "3704c8d23324a3fc0771ad2c1cef0aa4e9459f6b";
+      sha256 = "0hrdh4qjdaw3xzg5r4ybff2l3la7j5ls4h5ggv4g6jzzkncpnqgi";
+    };
+  }
+]
@@ -0,0 +1,19 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+let
+  fetch0 = {
+    type = "git";
+    url = "https://github.com/uber-go/atomic";
+    rev = "v1.3.2";
+    sha256 = "11pzvjys5ddjjgrv94pgk9pnip9yyb54z7idf33zk7p7xylpnsv6";
+  };
+in
+[
+  {
+    goPackagePath = "github.com/uber-go/atomic";
+    fetch = fetch0;
+  }
+  {
+    goPackagePath = "go.uber.org/atomic";
+    fetch = fetch0;
+  }
+]
@@ -0,0 +1,6 @@
+module github.com/adisbladis/vgo2nix/tests/test_dedupe_reuse
+
+require (
+	github.com/uber-go/atomic v1.3.2
+	go.uber.org/atomic v1.3.2
+)
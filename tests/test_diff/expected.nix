@@ -0,0 +1,30 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/ALTree/bigfloat";
+    fetch = {
+      type = "git";
+      url = "https://github.com/ALTree/bigfloat";
+      rev = "v0.2.0";
+      sha256 = "1vbqkj8nqc0ak8ppbzqm1mcvps8ww9ag77j4m9pf0mbrwf7r3nd9";
+    };
+  }
+  {
+    goPackagePath = "github.com/cespare/xxhash";
+    fetch = {
+      type = "git";
+      url = "https://github.com/cespare/xxhash";
+      rev = "v2.1.1";
+      sha256 = "1f3wyr9msnnz94szrkmnfps9wm40s5sp9i4ak0kl92zcrkmpy29a";
+    };
+  }
+  {
+    goPackagePath = "github.com/orivej/e";
+    fetch = {
+      type = "git";
+      url = "https://github.com/orivej/e";
+      rev = "ac3492690fda";
+      sha256 = "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr";
+    };
+  }
+]
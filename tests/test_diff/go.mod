@@ -0,0 +1,9 @@
+module github.com/adisbladis/vgo2nix/tests/test_diff
+
+go 1.16
+
+require (
+	github.com/ALTree/bigfloat v0.2.0
+	github.com/cespare/xxhash/v2 v2.1.1
+	github.com/orivej/e v0.0.0-20180728214217-ac3492690fda
+)
@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_empty_tree_sri
+
+require github.com/pkg/profile v1.2.1
@@ -0,0 +1,161 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/alecthomas/assert";
+    fetch = {
+      type = "FromGitHub";
+      owner = "alecthomas";
+      repo = "assert";
+      rev = "405dbfeb8e38";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/alecthomas/colour";
+    fetch = {
+      type = "FromGitHub";
+      owner = "alecthomas";
+      repo = "colour";
+      rev = "60882d9e2721";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/alecthomas/kingpin";
+    fetch = {
+      type = "FromGitHub";
+      owner = "alecthomas";
+      repo = "kingpin";
+      rev = "v2.2.6";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/alecthomas/repr";
+    fetch = {
+      type = "FromGitHub";
+      owner = "alecthomas";
+      repo = "repr";
+      rev = "117648cd9897";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/alecthomas/template";
+    fetch = {
+      type = "FromGitHub";
+      owner = "alecthomas";
+      repo = "template";
+      rev = "a0175ee3bccc";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/alecthomas/units";
+    fetch = {
+      type = "FromGitHub";
+      owner = "alecthomas";
+      repo = "units";
+      rev = "2efee857e7cf";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/davecgh/go-spew";
+    fetch = {
+      type = "FromGitHub";
+      owner = "davecgh";
+      repo = "go-spew";
+      rev = "v1.1.1";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/mattn/go-isatty";
+    fetch = {
+      type = "FromGitHub";
+      owner = "mattn";
+      repo = "go-isatty";
+      rev = "v0.0.3";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/orivej/e";
+    fetch = {
+      type = "FromGitHub";
+      owner = "orivej";
+      repo = "e";
+      rev = "ac3492690fda";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/orivej/go-nix";
+    fetch = {
+      type = "FromGitHub";
+      owner = "orivej";
+      repo = "go-nix";
+      rev = "dae45d921a44";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/pkg/profile";
+    fetch = {
+      type = "FromGitHub";
+      owner = "pkg";
+      repo = "profile";
+      rev = "v1.2.1";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/pmezard/go-difflib";
+    fetch = {
+      type = "FromGitHub";
+      owner = "pmezard";
+      repo = "go-difflib";
+      rev = "v1.0.0";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/sergi/go-diff";
+    fetch = {
+      type = "FromGitHub";
+      owner = "sergi";
+      repo = "go-diff";
+      rev = "v1.0.0";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "github.com/stretchr/testify";
+    fetch = {
+      type = "FromGitHub";
+      owner = "stretchr";
+      repo = "testify";
+      rev = "v1.2.2";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+  {
+    goPackagePath = "golang.org/x/sys";
+    fetch = {
+      type = "git";
+      url = "https://go.googlesource.com/sys";
+      rev = "d99a578cf41b";
+      sha256 = "10q9xx4pmnq92qn6ff4xp7n1hx766wvw2rf7pqcd6rx5plgwz8cm";
+    };
+  }
+  {
+    goPackagePath = "golang.org/x/tools";
+    fetch = {
+      type = "git";
+      url = "https://go.googlesource.com/tools";
+      rev = "ded554d0681e";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+]
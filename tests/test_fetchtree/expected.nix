@@ -0,0 +1,12 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/pkg/profile";
+    fetch = {
+      type = "git";
+      url = "https://github.com/pkg/profile";
+      rev = "3a8809bd8a80f8ecfe4ee1b34b7f2c3d2e4b5f60";
+      narHash = "sha256-edKIDucWJQabDkRDixUCVzIT8S3pdcn0N3m6iUT+UYY=";
+    };
+  }
+]
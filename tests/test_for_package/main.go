@@ -0,0 +1,7 @@
+package main
+
+import "golang.org/x/tools/go/vcs"
+
+func main() {
+	vcs.ShowCmd = true
+}
@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/locked";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/locked";
+      rev = "v1.0.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/updated";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/updated";
+      rev = "v1.0.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+]
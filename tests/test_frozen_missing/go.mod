@@ -0,0 +1,6 @@
+module github.com/adisbladis/vgo2nix/tests/test_frozen_missing
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/pkg/profile v1.2.1
+)
@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_go_binary
+
+go 1.16
@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_godeps_json
+
+require github.com/ugorji/go/codec v0.0.0-20190126102652-8fd0f8d918c8
@@ -0,0 +1,7 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+schema = 3
+
+[mod]
+  [mod."github.com/orivej/e"]
+    version = "v0.0.0-20180728214217-ac3492690fda"
+    hash = "sha256-edKIDucWJQabDkRDixUCVzIT8S3pdcn0N3m6iUT+UYY="
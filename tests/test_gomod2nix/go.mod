@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_gomod2nix
+
+require github.com/orivej/e v0.0.0-20180728214217-ac3492690fda
@@ -0,0 +1,5 @@
+module github.com/adisbladis/vgo2nix/tests/test_gopkg_in
+
+go 1.16
+
+require gopkg.in/yaml.v2 v2.4.0
@@ -0,0 +1,57 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/legacy";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/legacy";
+      rev = "v2.3.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/nested";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/nested";
+      rev = "v2.1.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/prerelease";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/prerelease";
+      rev = "v3.0.0-rc.1";
+      sha256 = "06w45aqz2a6yrk25axbly2k5wmsccv8cspb94bfmz4izvw8h927n";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/pseudo";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/pseudo";
+      rev = "abcdef123456";
+      sha256 = "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/pseudopre";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/pseudopre";
+      rev = "0123456789ab";
+      sha256 = "0c1cn55m4rypmscgf0rrb88pn58j3ysvc2d0432dp3c6fqg6cnzw";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/vzero";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/vzero";
+      rev = "v2.0.0";
+      sha256 = "0dlszlshlxbmmfxj5hlwgv3r22x0y1af45gn1vd198nvvs3pnvfs";
+    };
+  }
+]
@@ -0,0 +1,34 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  # Gone from go.mod, the comment goes with it
+  {
+    goPackagePath = "github.com/pkg/errors";
+    fetch = {
+      type = "git";
+      url = "https://github.com/pkg/errors";
+      rev = "v0.9.1";
+      sha256 = "1761pybhc2kqr6v5fm8faj08x9bql8427yqg6vnfv6nhrasx1mwq";
+    };
+  }
+  {
+    goPackagePath = "github.com/cespare/xxhash";
+    # Kept as it is, along with this comment
+    fetch = {
+      type = "git";
+      url = "https://github.com/cespare/xxhash";
+      rev = "v2.1.1";
+      sha256 = "0rl5rs8546zj1vzggv38w93wx0b5dvav7yy5hzxa8kw7iikv1cgr";
+    };
+  }
+  # Pinned, do not bump
+  # (fetched again at the new rev, the comments stay)
+  {
+    goPackagePath = "github.com/orivej/e";
+    fetch = {
+      type = "git";
+      url = "https://github.com/orivej/e";
+      rev = "0f8c6d1d9e36";
+      sha256 = "0ckxsq9pwh8lqhkp1xh1xhw0dkxl3ryw8yy9kpadsv97qa7dwv62";
+    };
+  }
+]
@@ -0,0 +1,12 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/ugorji/go";
+    fetch = {
+      type = "git";
+      url = "https://github.com/ugorji/go";
+      rev = "8fd0f8d918c8";
+      sha256 = "0fq4k5239w29qv587a95ivzmdf5jnbhdmc4y2ny87nw3i3qmgsj4";
+    };
+  }
+]
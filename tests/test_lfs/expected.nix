@@ -0,0 +1,13 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/ugorji/go";
+    fetch = {
+      type = "git";
+      url = "https://github.com/ugorji/go";
+      rev = "8fd0f8d918c8";
+      sha256 = "1111111111111111111111111111111111111111111111111111";
+      fetchLFS = true;
+    };
+  }
+]
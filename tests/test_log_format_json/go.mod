@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_log_format_json
+
+require github.com/pkg/profile v1.2.1
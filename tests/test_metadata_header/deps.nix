@@ -0,0 +1,17 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+# vgo2nix version: v0.1.0
+# go version: go1.16.15
+# modules: 1
+# generated at: 2021-03-04T05:06:07Z
+[
+  # Pinned, the metadata above is no comment of this entry
+  {
+    goPackagePath = "github.com/orivej/e";
+    fetch = {
+      type = "git";
+      url = "https://github.com/orivej/e";
+      rev = "ac3492690fda";
+      sha256 = "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr";
+    };
+  }
+]
@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/mono";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/mono";
+      rev = "services/api/client/v2.0.1";
+      sha256 = "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/tools";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/tools";
+      rev = "cmd/lint/v1.2.3";
+      sha256 = "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr";
+    };
+  }
+]
@@ -0,0 +1,30 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/only-a";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/only-a";
+      rev = "v0.1.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/only-b";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/only-b";
+      rev = "v2.0.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/shared";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/shared";
+      rev = "v1.2.0";
+      sha256 = "0m5q4ryqaqnxdz5x9nh1ld2l2fihr4x2qacbmvxkfs14plmjzqx9";
+    };
+  }
+]
@@ -0,0 +1,13 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  # Retagged upstream, see the "{" in the rev
+  {
+    goPackagePath = "github.com/example/kept";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/kept";
+      rev = "v1.0.0-\${BUILD}\"\\x{";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+]
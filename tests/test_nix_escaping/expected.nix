@@ -0,0 +1,22 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  # Retagged upstream, see the "{" in the rev
+  {
+    goPackagePath = "github.com/example/kept";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/kept";
+      rev = "v1.0.0-\${BUILD}\"\\x{";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/overridden";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/overridden";
+      rev = "v2.0.0-\${BUILD}\"\\x";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+]
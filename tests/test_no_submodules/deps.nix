@@ -0,0 +1,13 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/vendoredtoo";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/vendoredtoo";
+      rev = "v0.4.0";
+      sha256 = "0c1cn55m4rypmscgf0rrb88pn58j3ysvc2d0432dp3c6fqg6cnzw";
+      fetchSubmodules = false;
+    };
+  }
+]
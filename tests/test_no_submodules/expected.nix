@@ -0,0 +1,32 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/plain";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/plain";
+      rev = "v1.0.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/vendored";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/vendored";
+      rev = "v2.1.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+      fetchSubmodules = false;
+    };
+  }
+  {
+    goPackagePath = "github.com/example/vendoredtoo";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/vendoredtoo";
+      rev = "v0.4.0";
+      sha256 = "0c1cn55m4rypmscgf0rrb88pn58j3ysvc2d0432dp3c6fqg6cnzw";
+      fetchSubmodules = false;
+    };
+  }
+]
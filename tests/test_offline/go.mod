@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_offline
+
+go 1.16
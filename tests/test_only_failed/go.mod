@@ -0,0 +1,6 @@
+module github.com/adisbladis/vgo2nix/tests/test_only_failed
+
+require (
+	github.com/orivej/go-nix v0.0.0-20180830055821-dae45d921a44
+	golang.org/x/tools v0.0.0-20180723204246-ded554d0681e
+)
@@ -0,0 +1,5 @@
+module github.com/adisbladis/vgo2nix/tests/test_prefetch_cmd
+
+go 1.16
+
+require github.com/orivej/e v0.0.0-20180728214217-ac3492690fda
@@ -0,0 +1,30 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/broken";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/broken";
+      rev = "v1.0.0";
+      sha256 = "0c1cn55m4rypmscgf0rrb88pn58j3ysvc2d0432dp3c6fqg6cnzw";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/gone";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/gone";
+      rev = "v0.3.1";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/kept";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/kept";
+      rev = "v1.0.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+]
@@ -0,0 +1,11 @@
+module github.com/adisbladis/vgo2nix/tests/test_pseudo_versions
+
+go 1.16
+
+require (
+	code.cloudfoundry.org/lager v2.0.0+incompatible
+	github.com/cespare/xxhash/v2 v2.1.1
+	github.com/pierrec/lz4 v2.0.4-0.20180826165652-dbe9298ce099+incompatible
+	github.com/robfig/cron/v3 v3.0.2-0.20210106135023-bc59245fe10e
+	k8s.io/kubernetes v1.11.8-beta.0.0.20190124204751-3a10094374f2
+)
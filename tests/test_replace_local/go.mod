@@ -0,0 +1,10 @@
+module testmodule
+
+require (
+	example.com/local v0.0.0-00010101000000-000000000000
+	github.com/pkg/errors v0.8.1
+)
+
+replace example.com/local => ./local
+
+replace github.com/pkg/errors => github.com/pkg/errors v0.9.1
@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/mono";
+    fetch = {
+      type = "git";
+      url = "https://github.com/fork/mono";
+      rev = "sub/v0.3.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/upstream";
+    fetch = {
+      type = "git";
+      url = "https://github.com/fork/upstream";
+      rev = "v1.2.4";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+]
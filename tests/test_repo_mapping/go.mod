@@ -0,0 +1,8 @@
+module github.com/adisbladis/vgo2nix/tests/test_repo_mapping
+
+go 1.16
+
+require (
+	gopkg.in/yaml.v2 v2.4.0
+	k8s.io/kubernetes v1.11.8-beta.0.0.20190124204751-3a10094374f2
+)
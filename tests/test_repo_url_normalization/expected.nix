@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "bitbucket.org/example/widgets";
+    fetch = {
+      type = "git";
+      url = "https://bitbucket.org/example/widgets.git";
+      rev = "v1.2.0";
+      sha256 = "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr";
+    };
+  }
+  {
+    goPackagePath = "git.sr.ht/~example/getopt";
+    fetch = {
+      type = "git";
+      url = "https://git.sr.ht/~example/getopt";
+      rev = "v0.3.1";
+      sha256 = "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr";
+    };
+  }
+]
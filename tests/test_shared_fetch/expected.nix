@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "example.com/api";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/platform";
+      rev = "v1.4.0";
+      sha256 = "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr";
+    };
+  }
+  {
+    goPackagePath = "example.com/sdk";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/platform";
+      rev = "v1.4.0";
+      sha256 = "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr";
+    };
+  }
+]
@@ -0,0 +1,9 @@
+module github.com/adisbladis/vgo2nix/tests/test_sort_url
+
+go 1.16
+
+require (
+	code.cloudfoundry.org/lager v2.0.0+incompatible
+	github.com/orivej/e v0.0.0-20180728214217-ac3492690fda
+	k8s.io/kubernetes v1.11.8-beta.0.0.20190124204751-3a10094374f2
+)
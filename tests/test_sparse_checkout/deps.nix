@@ -0,0 +1,13 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/big";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/big";
+      rev = "pkg/v3.0.0";
+      sha256 = "0c1cn55m4rypmscgf0rrb88pn58j3ysvc2d0432dp3c6fqg6cnzw";
+      sparseCheckout = [ "pkg" ];
+    };
+  }
+]
@@ -0,0 +1,41 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/big";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/big";
+      rev = "pkg/v3.0.0";
+      sha256 = "0c1cn55m4rypmscgf0rrb88pn58j3ysvc2d0432dp3c6fqg6cnzw";
+      sparseCheckout = [ "pkg" ];
+    };
+  }
+  {
+    goPackagePath = "github.com/example/mono";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/mono";
+      rev = "services/api/v1.4.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+      sparseCheckout = [ "services/api" ];
+    };
+  }
+  {
+    goPackagePath = "github.com/example/single";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/single";
+      rev = "v0.2.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/tools";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/tools";
+      rev = "cmd/lint/v1.2.3";
+      sha256 = "06w45aqz2a6yrk25axbly2k5wmsccv8cspb94bfmz4izvw8h927n";
+    };
+  }
+]
@@ -0,0 +1,21 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/testify";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/testify";
+      rev = "v1.7.0";
+      sha256 = "04rlq9hc3ccww9sbsrl48fl6wbjprb136rqxyr7dmgfj444aml56";
+    };
+  }
+  {
+    goPackagePath = "github.com/example/tool";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/tool";
+      rev = "v0.3.0";
+      sha256 = "0m5q4ryqaqnxdz5x9nh1ld2l2fihr4x2qacbmvxkfs14plmjzqx9";
+    };
+  }
+]
@@ -0,0 +1,12 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/orivej/e";
+    fetch = {
+      type = "git";
+      url = "https://github.com/orivej/e";
+      rev = "ac3492690fda";
+      hash = "sha256-edKIDucWJQabDkRDixUCVzIT8S3pdcn0N3m6iUT+UYY=";
+    };
+  }
+]
@@ -0,0 +1,14 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/example/dep";
+    module = "github.com/example/dep@v1.0.0";
+    fetch = {
+      type = "git";
+      url = "https://github.com/example/dep";
+      rev = "v1.0.0";
+      sha256 = "05v1rgzdqc8razf702laagrvhvx68xd9yxxmzd3dyz0d6425pdrp";
+      fetchSubmodules = true;
+    };
+  }
+]
@@ -0,0 +1,5 @@
+module github.com/adisbladis/vgo2nix/tests/test_toolchain
+
+go 1.21
+
+toolchain go1.21.0
@@ -0,0 +1,13 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/pkg/profile";
+    fetch = {
+      type = "zip";
+      url = "https://proxy.golang.org/github.com/pkg/profile/@v/v1.2.1.zip";
+      rev = "v1.2.1";
+      subdir = "pkg/profile@v1.2.1";
+      sha256 = "0000000000000000000000000000000000000000000000000000";
+    };
+  }
+]
@@ -0,0 +1,12 @@
+# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "launchpad.net/gocheck";
+    fetch = {
+      type = "bzr";
+      url = "https://launchpad.net/gocheck";
+      rev = "87";
+      sha256 = "1y9fa2mv61if51gpik9isls48idsdz87zkm1p3my7swjdix7fcl0";
+    };
+  }
+]
@@ -0,0 +1,3 @@
+module github.com/adisbladis/vgo2nix/tests/test_vcs_bzr
+
+require launchpad.net/gocheck v0.0.0-20140225173054-000000000087
@@ -0,0 +1,8 @@
+module github.com/adisbladis/vgo2nix/tests/test_vendored
+
+go 1.16
+
+require (
+	github.com/ALTree/bigfloat v0.2.0
+	github.com/orivej/e v0.0.0-20180728214217-ac3492690fda
+)
@@ -0,0 +1,5 @@
+module github.com/adisbladis/vgo2nix/tests/test_workspace/a
+
+go 1.18
+
+require github.com/pkg/errors v0.8.1
@@ -0,0 +1,8 @@
+module github.com/adisbladis/vgo2nix/tests/test_workspace/b
+
+go 1.18
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/pkg/profile v1.2.1
+)
@@ -0,0 +1,78 @@
+package vgo2nix
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// loadVersionAllowlist reads a file of permitted module@version pins, one per
+// line. Empty lines and lines starting with # are ignored.
+func loadVersionAllowlist(filePath string) (map[string]bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	allowed := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "@") {
+			return nil, fmt.Errorf("%s:%d: expected module@version, got \"%s\"", filePath, lineno, line)
+		}
+		allowed[line] = true
+	}
+
+	return allowed, scanner.Err()
+}
+
+// checkVersionAllowlist fails listing every module whose version is not
+// permitted.
+func checkVersionAllowlist(entries []*modEntry, allowed map[string]bool) error {
+	var violations []string
+	for _, entry := range entries {
+		moduleVersion := entry.importPath + "@" + entry.version
+		if !allowed[moduleVersion] {
+			violations = append(violations, moduleVersion)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("Module versions not in the allowlist:\n  %s", strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+// repoHost returns the host of a repository URL, including the scp-like form
+// git uses for SSH, or "" if it has none.
+func repoHost(repoURL string) string {
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	// user@host:path
+	if i := strings.Index(repoURL, ":"); i > 0 && !strings.Contains(repoURL[:i], "/") {
+		return repoURL[strings.LastIndex(repoURL[:i], "@")+1 : i]
+	}
+	return ""
+}
+
+// hostAllowed reports whether the host of repoURL matches one of the globs
+func hostAllowed(repoURL string, globs []string) bool {
+	host := repoHost(repoURL)
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, host); ok && host != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package vgo2nix
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// stdioPath stands for stdin as input file and for stdout as output file
+const stdioPath = "-"
+
+// writeFileAtomic replaces filePath with data through a temporary file in
+// the same directory, so that a failed or interrupted write leaves the
+// previous file as it was. A symlink is written through to its target, and
+// stdioPath writes data to stdout.
+func writeFileAtomic(filePath string, data []byte) error {
+	return writeFileChecked(filePath, data, nil)
+}
+
+// writeFileChecked is writeFileAtomic running check on the temporary file
+// before it replaces filePath, which is left as it was if check fails. For
+// stdout the temporary file is in the system temporary directory.
+func writeFileChecked(filePath string, data []byte, check func(tmpPath string) error) (err error) {
+	dir := filepath.Dir(filePath)
+	if filePath == stdioPath {
+		if check == nil {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		dir = os.TempDir()
+	} else if target, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = target
+		dir = filepath.Dir(filePath)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil || filePath == stdioPath {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	// CreateTemp creates files only readable by the owner
+	if err := tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if check != nil {
+		if err := check(tmp.Name()); err != nil {
+			return err
+		}
+	}
+	if filePath == stdioPath {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
@@ -0,0 +1,69 @@
+package vgo2nix
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "deps.nix")
+	if err := os.WriteFile(filePath, []byte("previous\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(filePath, []byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "next\n" {
+		t.Errorf("deps.nix is %q, expected %q", data, "next\n")
+	}
+	if info, err := os.Stat(filePath); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("deps.nix has mode %v, expected %v", info.Mode().Perm(), os.FileMode(0644))
+	}
+
+	// A symlink stays a symlink, its target is replaced
+	if runtime.GOOS != "windows" {
+		link := filepath.Join(dir, "link.nix")
+		if err := os.Symlink("deps.nix", link); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFileAtomic(link, []byte("linked\n")); err != nil {
+			t.Fatal(err)
+		}
+		if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("link.nix is no longer a symlink: %v", err)
+		}
+		if data, err := os.ReadFile(filePath); err != nil || string(data) != "linked\n" {
+			t.Errorf("deps.nix is %q through the symlink, expected %q: %v", data, "linked\n", err)
+		}
+	}
+
+	// A write that fails leaves no temporary file behind
+	if err := os.Mkdir(filepath.Join(dir, "dir.nix"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dir.nix", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "dir.nix"), []byte("next\n")); err == nil {
+		t.Errorf("Replacing a directory did not fail")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if name := entry.Name(); name != "deps.nix" && name != "link.nix" && name != "dir.nix" {
+			t.Errorf("%s was left behind", name)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseBranchHints parses module=branch pairs. The branch is passed on as the
+// branch the checkout is made on, which nix-prefetch-git and fetchgit need to
+// agree on to reproduce the same checkout.
+func parseBranchHints(hints []string) (map[string]string, error) {
+	branches := make(map[string]string)
+	for _, hint := range hints {
+		parts := strings.SplitN(hint, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid branch hint \"%s\", expected module=branch", hint)
+		}
+		branches[parts[0]] = parts[1]
+	}
+	return branches, nil
+}
@@ -0,0 +1,89 @@
+package vgo2nix
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type hashCacheEntry struct {
+	// The rev the fetch resolved to, differs from the requested one for fetchtree
+	Rev     string    `json:"rev"`
+	Sha256  string    `json:"sha256"`
+	Commit  string    `json:"commit,omitempty"`
+	Date    string    `json:"date,omitempty"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// hashCache persists the hashes of all fetches keyed by fetcher, URL and rev so
+// they survive changes to deps.nix and can be shared between projects.
+type hashCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*hashCacheEntry
+}
+
+// loadHashCache reads the cache at filePath. A missing file is an empty cache,
+// and so is one that cannot be parsed, e.g. after a run was killed while
+// writing it, which is replaced by the next save.
+func loadHashCache(filePath string) (*hashCache, error) {
+	c := &hashCache{
+		path:    filePath,
+		entries: make(map[string]*hashCacheEntry),
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		logf("Ignoring the hash cache %s, it cannot be parsed: %v", filePath, err)
+		c.entries = make(map[string]*hashCacheEntry)
+	}
+
+	return c, nil
+}
+
+func hashCacheKey(fetcher string, url string, rev string) string {
+	return fetcher + ":" + url + "@" + rev
+}
+
+func (c *hashCache) get(fetcher string, url string, rev string) *hashCacheEntry {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[hashCacheKey(fetcher, url, rev)]
+}
+
+func (c *hashCache) put(fetcher string, url string, rev string, entry *hashCacheEntry) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[hashCacheKey(fetcher, url, rev)] = entry
+}
+
+func (c *hashCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, append(data, '\n'))
+}
@@ -0,0 +1,56 @@
+package vgo2nix
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// exitDrift is the exit status of --check when deps.nix does not match go.mod
+const exitDrift = 3
+
+// checkDeps compares prevDeps against the modules of go.mod without fetching
+// anything and describes every difference: modules required at another rev
+// than deps.nix has, modules without an entry and entries no module uses.
+// Modules are matched to entries by path alone, so no repository needs to be
+// resolved.
+func checkDeps(ctx context.Context, opts *options, prevDeps map[string]*Package) ([]string, error) {
+	entries, err := getModules(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.exclude) > 0 {
+		entries = excludeModules(entries, opts.exclude)
+	}
+
+	var drift []string
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		prevPkg := lookupPrevDep(prevDeps, entry.importPath)
+		if prevPkg == nil {
+			drift = append(drift, fmt.Sprintf("Missing %s@%s", entry.importPath, entry.version))
+			continue
+		}
+		used[prevPkg.GoPackagePath] = true
+
+		rev := entry.rev
+		if override := opts.overrides[entry.importPath]; override != nil {
+			rev = override.Rev
+		} else if prevPkg.Fetcher == fetcherProxy {
+			rev = entry.version
+		}
+		if !revMatches(prevPkg.Rev, rev) {
+			drift = append(drift, fmt.Sprintf("Changed %s: deps.nix has %s, go.mod requires %s", entry.importPath, prevPkg.Rev, entry.version))
+		}
+	}
+
+	var stale []string
+	for goPackagePath := range prevDeps {
+		if !used[goPackagePath] {
+			stale = append(stale, fmt.Sprintf("Stale %s", goPackagePath))
+		}
+	}
+	sort.Strings(stale)
+
+	return append(drift, stale...), nil
+}
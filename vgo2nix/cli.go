@@ -0,0 +1,727 @@
+package vgo2nix
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Exit statuses of Main. --check, --dry-run and --max-runtime have their own
+// as well, see exitDrift, exitWouldFetch and exitTimedOut.
+const (
+	exitFatal   = 1
+	exitPartial = 2
+)
+
+// exitStatus ends a run that did not fail outright with a status of its own
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// Main runs the command line tool with the flags of the process and exits
+// with its status. Errors exit with exitFatal, programs embedding vgo2nix
+// call Generate instead.
+func Main() {
+	status := 0
+	var exit exitStatus
+	if err := run(); errors.As(err, &exit) {
+		status = int(exit)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		status = exitFatal
+	}
+	os.Exit(status)
+}
+
+// run is the command line tool, returning an exitStatus for runs that end
+// with another status than 0 without failing
+func run() error {
+	var keepGoing = flag.Bool("keep-going", false, "Leave out modules that fail to fetch instead of failing, exiting with 2 if any did")
+	var goDirs stringList
+	flag.Var(&goDirs, "dir", "Go project directory (default ./), more comma separated or repeated directories add the modules of other projects (relative to the first)")
+	var resolve = flag.String("resolve", "", "Version to use of modules listed at different versions in several --dir, only highest (default fail)")
+	var out = flag.String("outfile", "deps.nix", "deps.nix output file (relative to project directory), - for stdout")
+	var in = flag.String("infile", "deps.nix", "deps.nix input file (relative to project directory), - for stdin")
+	var jobs = flag.Int("jobs", 20, "Number of parallel jobs, 0 for twice the number of CPUs")
+	var maxJobs = flag.Int("max-jobs", 0, "Number of parallel fetches to raise the concurrency up to, e.g. again after backing off from rate limits (default --jobs)")
+	var minJobs = flag.Int("min-jobs", 1, "Number of parallel fetches to keep when backing off from rate limits")
+	var fetcher = flag.String("fetcher", fetcherFetchgit, "Fetcher to emit entries for (fetchgit, fetchtree, github or proxy)")
+	var githubFetch = flag.Bool("github-fetch", false, "Emit fetchFromGitHub entries for GitHub repositories, same as --fetcher=github")
+	var useProxy = flag.Bool("use-proxy", false, "Emit fetchzip entries for the module zips of the GOPROXY instead of fetching repositories, same as --fetcher=proxy")
+	var prefetchCmd = flag.String("prefetch-cmd", "", "Command to run instead of nix-prefetch-git, taking the same arguments and printing the same JSON")
+	var fetchTimeout = flag.Duration("fetch-timeout", 0, "Kill fetches of a single module running longer than this, e.g. 10m (default no limit)")
+	var maxRuntime = flag.Duration("max-runtime", 0, "Stop fetching after this duration and write the modules resolved so far (default no limit)")
+	var storeCheck = flag.Bool("store-check", false, "Reuse known hashes for a repo and rev if the fetch result is already in the Nix store")
+	var verifyGoSumFlag = flag.Bool("verify-gosum", false, "Verify the checkout of every fetched module against its hash in go.sum")
+	var goSumSidecar = flag.String("gosum-sidecar", "", "Also write the nix and go.sum hash of every module to this JSON file (relative to project directory)")
+	var mainModules = flag.String("main-module", "", "Comma separated module paths to exclude in addition to the main module")
+	var report = flag.String("report", "", "Write a summary of added, removed, updated and failed modules to this file, as JSON if it ends in .json (relative to project directory)")
+	var errorLog = flag.String("error-log", "", "Write the full errors of the modules that failed to fetch to this file (relative to project directory)")
+	var reportPruned = flag.String("report-pruned", "", "Write the goPackagePath of every input file entry no longer needed to this file, one per line (relative to project directory)")
+	var goBinary = flag.String("go-binary", "go", "go binary to list modules with, looked up in PATH unless it is a path")
+	var toolchain = flag.String("toolchain", "", "Go toolchain to list modules with, e.g. go1.22.0 or local (default the go.mod toolchain directive)")
+	var maxAge = flag.Duration("max-age", 0, "Fetch hashes again that were fetched longer ago than this (default trust them forever)")
+	var diff = flag.Bool("diff", false, "Print the modules added to, removed from and updated in the input file, with rev and sha256 changes on separate lines")
+	var check = flag.Bool("check", false, "Report how the input file differs from go.mod without fetching anything or writing the output file, exit with 3 if it does")
+	var printRepoRoots = flag.Bool("print-repo-roots", false, "Print the repository each module resolves to without fetching anything")
+	var printJSON = flag.Bool("json", false, "Print diagnostic output as JSON")
+	flag.StringVar(&logFormat, "log-format", logFormatText, "Format of progress messages, text or json for one JSON object per line")
+	var refresh = flag.String("refresh", "", "Comma separated modules to fetch again even if their hash is known")
+	var freeze = flag.Bool("freeze", false, "Like --frozen, but only reuse the entries of the input file as they are, failing if any module is at a rev it does not have")
+	var frozen = flag.Bool("frozen", false, "Fail instead of fetching if the hash of any module is not known from the input file or the hash cache")
+	var allowEmpty = flag.String("allow-empty", "", "Comma separated module@rev pairs whose source may legitimately be empty")
+	var adaptive = flag.Bool("concurrency-adaptive", false, "Adapt the number of parallel fetches to all transient failures rather than rate limits alone, between --min-jobs and --max-jobs")
+	var recordCommit = flag.Bool("record-commit", false, "Add the commit every rev resolved to as commit, to reuse hashes when a rev changes but its commit does not")
+	var annotateDate = flag.Bool("annotate-date", false, "Add the commit date of every module to its entry")
+	var quiet = flag.Bool("quiet", false, "Only show a single progress line on stderr instead of a message for every module")
+	var verbose = flag.Bool("verbose", false, "Show a message for every module, the default")
+	var timings = flag.Int("timings", 0, "Print the N slowest fetches with their durations and repository URLs at the end (default none)")
+	var progress = flag.Bool("progress", false, "Log a running count of the modules resolved so far")
+	var netrc = flag.String("netrc", "", "netrc file with credentials for fetching (relative to project directory)")
+	var configFile = flag.String("config", "", "JSON file with modules to exclude and revs and hashes to use instead of fetching (relative to project directory)")
+	var requireTagsFile = flag.String("require-tags-file", "", "Fail if any module@version is not listed in this file (relative to project directory)")
+	var allowedHosts = flag.String("allowed-hosts", "", "Comma separated globs of the hosts modules may be fetched from, e.g. github.com,*.gitlab.example.com (default every host)")
+	var stateDirPath = flag.String("state-dir", defaultStateDir(), "Directory to keep caches in between runs")
+	var resetState = flag.Bool("reset-state", false, "Discard everything in the state directory before running")
+	var cachePath = flag.String("cache", "", "Hash cache file to use instead of the one in the state directory (relative to project directory)")
+	var noCache = flag.Bool("no-cache", false, "Neither reuse hashes from the hash cache nor record fetched ones in it")
+	var format = flag.String("format", formatNix, "Format to write, nix for deps.nix, json for the deps.json of older nixpkgs versions or gomod2nix for gomod2nix.toml")
+	var sri = flag.Bool("sri", false, "Write SRI hashes (hash = \"sha256-...\") instead of base32 sha256 attributes to deps.nix")
+	var outputFormat = flag.String("output-format", "", "Builder to write the output for, buildGoPackage (deps.nix) or buildGoModule (gomod2nix.toml, same as --format=gomod2nix)")
+	var onlyFailed = flag.Bool("only-failed", false, "Keep the entries of the input file as they are and only fetch the modules missing from it")
+	var maxHostFailures = flag.Int("max-retries-per-host", 0, "Stop fetching from a host after this many fetches from it, retries included, failed in a row with network errors or timeouts and fail its remaining modules right away (default no limit)")
+	var retries = flag.Int("retries", 0, "Number of times to retry a failed fetch, waiting twice as long before every retry starting at one second")
+	var stripVPrefix = flag.String("strip-v-prefix", "", "Comma separated hosts to retry fetching a version without its v prefix from, for repos tagging 1.2.3 rather than v1.2.3")
+	var forPackage = flag.String("for-package", "", "Only include the modules needed to build this package, e.g. ./cmd/foo (default all modules)")
+	var leaveDotGit = flag.Bool("leave-dot-git", false, "Keep the .git directory in the checkout of every module (fetchgit leaveDotGit)")
+	var deepClone = flag.Bool("deep-clone", false, "Fetch the whole history of every module, which keeps .git as well (fetchgit deepClone)")
+	var lfs = flag.String("lfs", "", "Comma separated globs of module paths to fetch git-lfs content for, e.g. github.com/foo/*")
+	var noSubmodules = flag.Bool("no-submodules", false, "Fetch every module without its submodules and record fetchSubmodules = false")
+	var sparse = flag.String("sparse-checkout", "", "Comma separated globs of module paths to only check out the directory of in their repository, e.g. github.com/foo/monorepo")
+	var dryRun = flag.Bool("dry-run", false, "List the modules that would be fetched without fetching them or writing the output file, exit with 4 if there are any")
+	var smoke = flag.Bool("smoke-test", false, "Build the fetches of all modules with nix-build after writing the output file")
+	var branchHints stringList
+	flag.Var(&branchHints, "branch-hint", "Fetch the rev of a module from this branch and record it as branchName (module=branch), may be given multiple times")
+	var metadata = flag.Bool("metadata", false, "Record the vgo2nix and go versions and the number of modules below the header of the output file")
+	var timestamp = flag.Bool("timestamp", false, "Record the time of the run along with --metadata, which makes every run write a different file")
+	var sortBy = flag.String("sort", sortPath, "Order of the entries in the output, path for goPackagePath or url to group them by repository")
+	var validate = flag.Bool("validate", false, "Check that the output file parses with nix-instantiate --parse before writing it, skipped if nix-instantiate is missing")
+	var templateFile = flag.String("template", "", "Go text/template file to render every deps.nix entry with instead of the built-in format (relative to project directory)")
+	var dedupe = flag.Bool("dedupe-output", false, "Bind fetches shared by several entries once with let instead of repeating them")
+	var modulesJSON = flag.String("modules-json", "", "Read the modules from this file with the output of 'go list -json -m all' instead of running go (relative to project directory)")
+	var offline = flag.Bool("offline", false, "Reuse the modules listed by the last run for the project instead of running go, as long as go.mod and go.sum did not change")
+	var splitDev = flag.String("split-dev", "", "Write the modules not needed to build the packages of the main module, e.g. test and tool dependencies, to this file instead of the output file (relative to project directory)")
+	var vendored = flag.Bool("vendored", false, "Only include the modules present in vendor/modules.txt, at the versions recorded there")
+	var modMode = flag.String("mod", "", "Module download mode to list modules with (mod, readonly or vendor, default what go picks for the project)")
+	var repoMappings stringList
+	flag.Var(&repoMappings, "repo-mapping", "Fetch modules under an import path prefix from this git repository instead of looking it up (prefix=url, or prefix/=url/ for a repository per path element), may be given multiple times")
+	var mirrors stringList
+	flag.Var(&mirrors, "mirror", "Fetch repositories whose URL starts with from from a mirror whose URL starts with to instead (from=to), may be given multiple times")
+	var emitOriginalURL = flag.Bool("emit-original-url", false, "Write the URLs of the repositories rather than of their mirrors to the output file")
+	var only stringList
+	flag.Var(&only, "only", "Only fetch this module, even if its hash is known, and keep the entries of all others as they are, may be given multiple times")
+	var strictRoots = flag.Bool("strict-roots", false, "Fail instead of warning when an import path resolves to a repository root outside of it")
+	var gitConfig stringList
+	flag.Var(&gitConfig, "git-config", "Extra git configuration (key=value) for fetching, may be given multiple times")
+	var submoduleRewrites stringList
+	flag.Var(&submoduleRewrites, "submodule-url-rewrite", "Rewrite submodule URLs starting with from to start with to instead (from=to), may be given multiple times")
+	flag.Parse()
+
+	if *githubFetch {
+		if *fetcher != fetcherFetchgit && *fetcher != fetcherGitHub {
+			return fmt.Errorf("--github-fetch cannot be combined with --fetcher=%s", *fetcher)
+		}
+		*fetcher = fetcherGitHub
+	}
+	if *useProxy {
+		if *fetcher != fetcherFetchgit && *fetcher != fetcherProxy {
+			return fmt.Errorf("--use-proxy cannot be combined with --fetcher=%s", *fetcher)
+		}
+		*fetcher = fetcherProxy
+	}
+	if *freeze {
+		*frozen = true
+	}
+	dirs := splitList(strings.Join(goDirs, ","))
+	if len(dirs) == 0 {
+		dirs = []string{"./"}
+	}
+	if *resolve != "" && *resolve != "highest" {
+		return fmt.Errorf("Unknown --resolve \"%s\", only highest is supported", *resolve)
+	}
+	if len(dirs) > 1 && (*modulesJSON != "" || *forPackage != "") {
+		return fmt.Errorf("Several --dir cannot be combined with --modules-json or --for-package, which apply to a single project")
+	}
+	if *fetcher != fetcherFetchgit && *fetcher != fetcherFetchTree && *fetcher != fetcherGitHub && *fetcher != fetcherProxy {
+		return fmt.Errorf("Unknown fetcher \"%s\"", *fetcher)
+	}
+	if *lfs != "" && *fetcher != fetcherFetchgit {
+		return fmt.Errorf("--lfs is only supported by the %s fetcher", fetcherFetchgit)
+	}
+	if (*leaveDotGit || *deepClone) && *fetcher != fetcherFetchgit {
+		return fmt.Errorf("--leave-dot-git and --deep-clone are only supported by the %s fetcher", fetcherFetchgit)
+	}
+	if *modMode != "" && *modMode != "mod" && *modMode != "readonly" && *modMode != "vendor" {
+		return fmt.Errorf("Unknown module download mode \"%s\"", *modMode)
+	}
+	if *modulesJSON != "" && (*forPackage != "" || *toolchain != "" || *modMode != "") {
+		return fmt.Errorf("--modules-json cannot be combined with --for-package, --toolchain or --mod, which need go to list the modules")
+	}
+	if *offline && *modulesJSON != "" {
+		return fmt.Errorf("--offline cannot be combined with --modules-json")
+	}
+	if *offline && *stateDirPath == "" {
+		return fmt.Errorf("--offline needs the state directory to find the modules listed before")
+	}
+	if *splitDev != "" && (*modulesJSON != "" || *offline || *forPackage != "") {
+		return fmt.Errorf("--split-dev cannot be combined with --modules-json or --offline, which do not run go, or --for-package")
+	}
+	if *vendored && *modMode == "vendor" {
+		return fmt.Errorf("--vendored cannot be combined with --mod=vendor, go cannot list all modules from the vendor directory")
+	}
+	// Without a worker the results would be waited for forever
+	if *jobs < 0 {
+		return fmt.Errorf("--jobs must be at least 0, got %d", *jobs)
+	}
+	jobsAuto := *jobs == 0
+	if jobsAuto {
+		*jobs = autoJobs()
+	}
+	if *quiet && (*verbose || *progress || logFormat == logFormatJSON) {
+		return fmt.Errorf("--quiet cannot be combined with --verbose, --progress or --log-format=%s", logFormatJSON)
+	}
+	if logFormat != logFormatText && logFormat != logFormatJSON {
+		return fmt.Errorf("Unknown log format \"%s\"", logFormat)
+	}
+	switch *outputFormat {
+	case "", outputBuildGoPackage:
+		if *format == formatGomod2nix {
+			return fmt.Errorf("--output-format=%s cannot be combined with --format=%s", *outputFormat, *format)
+		}
+	case outputBuildGoModule:
+		if *format != formatNix && *format != formatGomod2nix {
+			return fmt.Errorf("--output-format=%s cannot be combined with --format=%s", *outputFormat, *format)
+		}
+		*format = formatGomod2nix
+	default:
+		return fmt.Errorf("Unknown output format \"%s\"", *outputFormat)
+	}
+	if *format != formatNix && *format != formatJSON && *format != formatGomod2nix {
+		return fmt.Errorf("Unknown format \"%s\"", *format)
+	}
+	if *format == formatGomod2nix {
+		// The modules are hashed as go downloads them, none of the fetch options apply
+		if *fetcher != fetcherFetchgit || *dryRun || *frozen || *onlyFailed || len(only) > 0 || *smoke || *report != "" || *reportPruned != "" || *errorLog != "" || *diff || *goSumSidecar != "" || *refresh != "" || len(mirrors) > 0 || *splitDev != "" {
+			return fmt.Errorf("The gomod2nix format cannot be combined with --fetcher, --dry-run, --frozen, --only-failed, --only, --smoke-test, --report, --report-pruned, --error-log, --diff, --gosum-sidecar, --refresh, --mirror or --split-dev")
+		}
+		if !flagSet("outfile") {
+			*out = "gomod2nix.toml"
+		}
+	}
+	if *sortBy != sortPath && *sortBy != sortURL {
+		return fmt.Errorf("Unknown sort order \"%s\"", *sortBy)
+	}
+	if *sortBy == sortURL && *format == formatGomod2nix {
+		return fmt.Errorf("--sort=%s cannot be combined with the gomod2nix format, which is keyed by module path", *sortBy)
+	}
+	if *metadata && *format != formatNix {
+		return fmt.Errorf("--metadata cannot be combined with --format=%s, only deps.nix has a header", *format)
+	}
+	if *timestamp && !*metadata {
+		return fmt.Errorf("--timestamp requires --metadata")
+	}
+	if *sri && *format == formatJSON {
+		return fmt.Errorf("--sri cannot be combined with --format=%s, deps.json only has sha256 attributes", *format)
+	}
+	if *sparse != "" && (*fetcher != fetcherFetchgit || *format != formatNix) {
+		return fmt.Errorf("--sparse-checkout is only supported by the %s fetcher and the %s format", fetcherFetchgit, formatNix)
+	}
+	if *validate && *format != formatNix {
+		return fmt.Errorf("--validate is only supported by the %s format", formatNix)
+	}
+	if *templateFile != "" && *format != formatNix {
+		return fmt.Errorf("--template is only supported by the %s format", formatNix)
+	}
+	if *noSubmodules && (*fetcher != fetcherFetchgit || *format != formatNix) {
+		return fmt.Errorf("--no-submodules is only supported by the %s fetcher and the %s format", fetcherFetchgit, formatNix)
+	}
+	if *format == formatJSON && *fetcher != fetcherFetchgit {
+		return fmt.Errorf("The json format only supports the %s fetcher", fetcherFetchgit)
+	}
+
+	if *check && *diff {
+		return fmt.Errorf("--check cannot be combined with --diff, it reports the differences to go.mod itself")
+	}
+	if *onlyFailed && (*frozen || *refresh != "") {
+		return fmt.Errorf("--only-failed cannot be combined with --frozen or --refresh")
+	}
+	if len(only) > 0 && (*onlyFailed || *frozen) {
+		return fmt.Errorf("--only cannot be combined with --only-failed or --frozen")
+	}
+	if *allowedHosts != "" && (*frozen || *fetcher == fetcherProxy || *format == formatGomod2nix) {
+		return fmt.Errorf("--allowed-hosts cannot be combined with --frozen, --fetcher=%s or --format=%s, which do not resolve the repositories of modules", fetcherProxy, formatGomod2nix)
+	}
+	for _, glob := range splitList(*allowedHosts) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("Invalid host glob \"%s\" in --allowed-hosts", glob)
+		}
+	}
+	if len(mirrors) > 0 && *fetcher != fetcherFetchgit && *fetcher != fetcherFetchTree {
+		return fmt.Errorf("--mirror is only supported by the %s and %s fetchers", fetcherFetchgit, fetcherFetchTree)
+	}
+	if *emitOriginalURL && len(mirrors) == 0 {
+		return fmt.Errorf("--emit-original-url requires --mirror")
+	}
+	if *verifyGoSumFlag && *fetcher != fetcherFetchgit && *fetcher != fetcherFetchTree {
+		return fmt.Errorf("--verify-gosum is only supported by the %s and %s fetchers", fetcherFetchgit, fetcherFetchTree)
+	}
+	if *smoke && *out == stdioPath {
+		return fmt.Errorf("--smoke-test cannot be combined with --outfile %s, it builds the output file", stdioPath)
+	}
+	if *noCache && *cachePath != "" {
+		return fmt.Errorf("--no-cache cannot be combined with --cache")
+	}
+	if *timings < 0 {
+		return fmt.Errorf("--timings must be at least 0, got %d", *timings)
+	}
+	if *maxHostFailures < 0 {
+		return fmt.Errorf("--max-retries-per-host must be at least 0, got %d", *maxHostFailures)
+	}
+	// Fetching starts with --jobs if it is given, and may go up to --max-jobs
+	startJobs := *jobs
+	if *maxJobs == 0 {
+		*maxJobs = *jobs
+	} else if !flagSet("jobs") {
+		startJobs = *maxJobs
+	}
+	if *minJobs < 1 || *maxJobs < *minJobs {
+		return fmt.Errorf("--min-jobs must be at least 1 and at most --max-jobs")
+	}
+
+	rewriteConfig, err := submoduleRewriteConfig(submoduleRewrites)
+	if err != nil {
+		return err
+	}
+	gitConfig = append(gitConfig, rewriteConfig...)
+
+	if *modulesJSON == "" {
+		path := *goBinary
+		if !*offline {
+			if path, err = exec.LookPath(*goBinary); err != nil {
+				return fmt.Errorf("The go binary %s was not found: %v", *goBinary, err)
+			}
+		}
+		// A path relative to the working directory has to survive the chdir
+		if strings.ContainsRune(*goBinary, filepath.Separator) {
+			if *goBinary, err = filepath.Abs(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = os.Chdir(dirs[0])
+	if err != nil {
+		return err
+	}
+
+	// A check prints the differences it finds and nothing else
+	if *check {
+		logOutput = io.Discard
+	}
+	// The output file goes to stdout, the messages must not end up in it
+	outName := *out
+	if *out == stdioPath {
+		logOutput = os.Stderr
+		outName = "stdout"
+	}
+	if jobsAuto {
+		logf("Using %d parallel jobs, twice the number of CPUs", *jobs)
+	}
+
+	// Load previous deps from deps.nix so we can reuse hashes for known revs
+	prevDeps := loadDepsNix(*in)
+	if *splitDev != "" {
+		// Modules moving between the files keep their hashes
+		for path, pkg := range loadDepsNix(*splitDev) {
+			if _, ok := prevDeps[path]; !ok {
+				prevDeps[path] = pkg
+			}
+		}
+	}
+	var state *stateDir
+	if *stateDirPath != "" {
+		state, err = openStateDir(*stateDirPath, *resetState)
+		if err != nil {
+			return err
+		}
+	}
+	repoRoots := newRepoRootCache()
+	if state != nil {
+		repoRoots, err = loadRepoRootCache(state.path("roots.json"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := repoRoots.save(); err != nil {
+				logf("Failed writing repository roots: %v", err)
+			}
+		}()
+	}
+	var cache *hashCache
+	switch {
+	case *noCache:
+	case *cachePath != "":
+		cache, err = loadHashCache(*cachePath)
+	case state != nil:
+		cache, err = loadHashCache(state.path("hashes.json"))
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if *maxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *maxRuntime)
+		defer cancel()
+	}
+
+	opts := &options{
+		keepGoing:    *keepGoing,
+		numJobs:      *maxJobs,
+		fetcher:      *fetcher,
+		storeCheck:   *storeCheck,
+		gitConfig:    gitConfig,
+		mainModules:  splitList(*mainModules),
+		toolchain:    *toolchain,
+		goBinary:     *goBinary,
+		hashCache:    cache,
+		maxAge:       *maxAge,
+		refresh:      splitList(*refresh),
+		frozen:       *frozen,
+		freeze:       *freeze,
+		allowEmpty:   splitList(*allowEmpty),
+		annotateDate: *annotateDate,
+		format:       *format,
+		sri:          *sri,
+		onlyFailed:   *onlyFailed,
+		only:         only,
+		allowedHosts: splitList(*allowedHosts),
+		strictRoots:  *strictRoots,
+		repoRoots:    repoRoots,
+		retries:      *retries,
+		stripVPrefix: splitList(*stripVPrefix),
+		forPackage:   *forPackage,
+		lfs:          splitList(*lfs),
+		sparseGlobs:  splitList(*sparse),
+		leaveDotGit:  *leaveDotGit,
+		noSubmodules: *noSubmodules,
+		deepClone:    *deepClone,
+		sortBy:       *sortBy,
+		dedupe:       *dedupe,
+		modMode:      *modMode,
+		vendored:     *vendored,
+		modulesJSON:  *modulesJSON,
+		moreDirs:     dirs[1:],
+		offline:      *offline,
+		dryRun:       *dryRun,
+		fetchTimeout: *fetchTimeout,
+		prefetchCmd:  *prefetchCmd,
+		recordCommit: *recordCommit,
+	}
+	if state != nil {
+		opts.moduleLists = &moduleListCache{path: state.path("modules.json")}
+	}
+	opts.branchHints, err = parseBranchHints(branchHints)
+	if err != nil {
+		return err
+	}
+	opts.mirrors, err = parseMirrors(mirrors)
+	if err != nil {
+		return err
+	}
+	opts.emitOriginalURL = *emitOriginalURL
+	opts.resolveHighest = *resolve == "highest"
+	if *validate {
+		if _, err := exec.LookPath("nix-instantiate"); err != nil {
+			logf("Skipping --validate, nix-instantiate was not found")
+		} else {
+			opts.validate = true
+		}
+	}
+	if *adaptive {
+		opts.adaptive = newAdaptiveLimiter(*minJobs, startJobs, *maxJobs)
+	} else {
+		opts.adaptive = newRateLimitLimiter(*minJobs, startJobs, *maxJobs)
+	}
+	if *timings > 0 {
+		opts.timings = newFetchTimings()
+	}
+	if *maxHostFailures > 0 {
+		opts.hostBreaker = newHostBreaker(*maxHostFailures)
+	}
+	if *progress {
+		opts.onResult = func(result *PackageResult, done int, total int) {
+			logf("Resolved %d/%d modules (%s)", done, total, result.ImportPath)
+		}
+	}
+	var progressBar *progressLine
+	if *quiet {
+		progressBar = newProgressLine(os.Stderr)
+		opts.onResult = progressBar.update
+	}
+	if opts.fetcher == fetcherProxy {
+		goproxy, err := goEnvVars(*goBinary, "GOPROXY")
+		if err != nil {
+			return fmt.Errorf("Failed reading GOPROXY: %v", err)
+		}
+		opts.proxyURL, err = goProxyURL(strings.TrimSpace(goproxy[0]))
+		if err != nil {
+			return err
+		}
+	}
+	if *verifyGoSumFlag {
+		opts.goSums = make(map[string]string)
+		for _, dir := range opts.moduleDirs() {
+			sums, err := loadGoSum(filepath.Join(dir, "go.sum"))
+			if err != nil {
+				return err
+			}
+			for key, sum := range sums {
+				opts.goSums[key] = sum
+			}
+		}
+	}
+	private, err := goEnvVars(*goBinary, "GOPRIVATE", "GONOSUMDB")
+	if err != nil {
+		return fmt.Errorf("Failed reading GOPRIVATE: %v", err)
+	}
+	opts.privatePatterns = strings.Join(strings.Fields(strings.Join(private, " ")), ",")
+	if *netrc != "" {
+		if _, err := os.Stat(*netrc); err != nil {
+			return err
+		}
+		// The prefetchers need not run in the project directory
+		opts.netrc, err = filepath.Abs(*netrc)
+		if err != nil {
+			return err
+		}
+		opts.gitConfig = append(opts.gitConfig, netrcCredentialHelper)
+	}
+	if *configFile != "" {
+		c, err := loadConfig(*configFile)
+		if err != nil {
+			return err
+		}
+		// Of mappings for the same prefix the first wins, the flags come first
+		repoMappings = append(repoMappings, c.repoMappings()...)
+		opts.exclude = c.Exclude
+		opts.overrides = c.Override
+		opts.leaveDotGitGlobs = c.LeaveDotGit
+		opts.deepCloneGlobs = c.DeepClone
+		opts.noSubmodulesGlobs = c.NoSubmodules
+	}
+	opts.repoMappings, err = parseRepoMappings(repoMappings)
+	if err != nil {
+		return err
+	}
+	if *templateFile != "" {
+		opts.template, err = loadTemplate(*templateFile)
+		if err != nil {
+			return fmt.Errorf("Invalid --template: %v", err)
+		}
+	}
+	if *requireTagsFile != "" {
+		opts.allowedVersions, err = loadVersionAllowlist(*requireTagsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *printRepoRoots {
+		logOutput = os.Stderr
+		if err := printRepoRootsOf(ctx, opts, *printJSON); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if *check {
+		drift, err := checkDeps(ctx, opts, prevDeps)
+		if err != nil {
+			return err
+		}
+		for _, line := range drift {
+			fmt.Println(line)
+		}
+		if len(drift) > 0 {
+			return exitStatus(exitDrift)
+		}
+		return nil
+	}
+
+	if *format == formatGomod2nix {
+		packages, err := gomod2nixPackages(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if err := writeGomod2nix(*out, packages); err != nil {
+			return err
+		}
+		logf("Wrote %s", outName)
+		return nil
+	}
+
+	var runtimeMods map[string]bool
+	if *splitDev != "" && !opts.dryRun {
+		runtimeMods, err = runtimeModules(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("Failed finding the modules needed at runtime: %v", err)
+		}
+	}
+
+	logged := logOutput
+	if *quiet {
+		logOutput = io.Discard
+	}
+	packages, failed, err := getPackages(ctx, opts, prevDeps)
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	if *quiet {
+		progressBar.finish()
+		logOutput = logged
+		// The errors were not shown as they happened
+		for _, result := range failed {
+			var wouldFetch *wouldFetchError
+			if !errors.As(result.Error, &wouldFetch) {
+				logf("Encountered error: %v", result.Error)
+			}
+		}
+	}
+	if err := cache.save(); err != nil {
+		logf("Failed writing hash cache: %v", err)
+	}
+	if *adaptive || opts.adaptive.adapted() {
+		logf("%s", opts.adaptive.stats())
+	}
+	if err != nil && !timedOut {
+		return err
+	}
+	if *timings > 0 {
+		opts.timings.logSlowest(*timings)
+	}
+
+	if opts.dryRun {
+		// Modules failing under --keep-going have been logged already
+		fetches, failed := dryRunSummary(packages, failed)
+		if *diff {
+			diffPackages(prevDeps, sortPackages(packagesByPath(append(packages, fetches...)))).log()
+		}
+		if timedOut {
+			logf("Timed out after %s, the summary only covers the modules resolved so far", *maxRuntime)
+			return exitStatus(exitTimedOut)
+		}
+		if len(fetches) > 0 || len(failed) > 0 {
+			return exitStatus(exitWouldFetch)
+		}
+		return nil
+	}
+
+	keepComments(packages, prevDeps)
+	runtimePackages := packages
+	var devPackages []*Package
+	if *splitDev != "" {
+		runtimePackages, devPackages = splitDevPackages(packages, runtimeMods)
+		logf("%d of %d modules are only needed for development", len(devPackages), len(packages))
+	}
+	if *metadata {
+		opts.headerMetadata = headerMetadata(ctx, opts, len(runtimePackages), *timestamp)
+	}
+	if err := writeDepsNix(*out, runtimePackages, opts); err != nil {
+		return err
+	}
+	logf("Wrote %s", outName)
+	if *splitDev != "" {
+		if *metadata {
+			opts.headerMetadata = headerMetadata(ctx, opts, len(devPackages), *timestamp)
+		}
+		if err := writeDepsNix(*splitDev, devPackages, opts); err != nil {
+			return err
+		}
+		logf("Wrote %s", *splitDev)
+	}
+	changes := diffPackages(prevDeps, packages)
+	if *diff {
+		changes.log()
+	}
+	if !timedOut {
+		// Modules not resolved before the timeout are missing but may still be needed
+		for _, pkg := range changes.Removed {
+			if *diff {
+				// Listed as removed already
+				break
+			}
+			logEventf(&logEvent{Event: "pruned", Path: pkg.GoPackagePath, Rev: pkg.Rev}, "Pruned %s %s, it is no longer needed", pkg.GoPackagePath, pkg.Rev)
+		}
+		if *reportPruned != "" {
+			if err := writePrunedReport(*reportPruned, changes.Removed); err != nil {
+				return err
+			}
+			logf("Wrote %s", *reportPruned)
+		}
+	}
+
+	if *smoke && !timedOut {
+		if err := smokeTest(ctx, *out, runtimePackages); err != nil {
+			return err
+		}
+		if *splitDev != "" {
+			if err := smokeTest(ctx, *splitDev, devPackages); err != nil {
+				return err
+			}
+		}
+		logf("Smoke test passed")
+	}
+
+	if *report != "" {
+		if err := writeReport(*report, changes, failed); err != nil {
+			return err
+		}
+		logf("Wrote %s", *report)
+	}
+
+	if *goSumSidecar != "" {
+		if err := writeGoSumSidecar(*goSumSidecar, "go.sum", packages); err != nil {
+			return err
+		}
+		logf("Wrote %s", *goSumSidecar)
+	}
+
+	if len(failed) > 0 {
+		logFailures(failed)
+	}
+	if *errorLog != "" {
+		if err := writeErrorLog(*errorLog, failed); err != nil {
+			return err
+		}
+		logf("Wrote %s", *errorLog)
+	}
+
+	if timedOut {
+		logf("Timed out after %s, %s only contains the %d modules resolved so far", *maxRuntime, outName, len(packages))
+		return exitStatus(exitTimedOut)
+	}
+	if len(failed) > 0 {
+		logf("%d modules failed to fetch", len(failed))
+		return exitStatus(exitPartial)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package vgo2nix
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var commitPrefix = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// resolveCommit returns the commit a tag or branch of the git repository at
+// url points to without fetching it. Annotated tags are peeled to the commit
+// they tag.
+func resolveCommit(ctx context.Context, timeout time.Duration, env []string, url string, rev string) (string, error) {
+	out, err := runPrefetch(ctx, timeout, env, "git", "ls-remote", url, rev, rev+"^{}")
+	if err != nil {
+		return "", classifyPrefetchError(err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	for _, ref := range []string{"refs/tags/" + rev + "^{}", "refs/tags/" + rev, "refs/heads/" + rev} {
+		if commit, ok := refs[ref]; ok {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("No tag or branch %s in %s", rev, url)
+}
+
+// knownCommit returns the commit, or a prefix of it, a previous entry was
+// fetched at. Entries without a recorded commit may have a commit hash as rev.
+func knownCommit(pkg *Package) string {
+	if pkg.Commit != "" {
+		return pkg.Commit
+	}
+	if commitPrefix.MatchString(pkg.Rev) {
+		return pkg.Rev
+	}
+	return ""
+}
+
+// sameCommit reports whether rev is the commit a previous entry was fetched
+// at under another name, e.g. a tag for what used to be a pseudo-version.
+// Only git repositories fetched with nix-prefetch-git know their commit.
+func (opts *options) sameCommit(ctx context.Context, env []string, prevPkg *Package, url string, rev string) bool {
+	if vcsOfFetcher(prevPkg.Fetcher) != "git" || prevPkg.Fetcher == fetcherGitHub || prevPkg.URL != url {
+		return false
+	}
+	prevCommit := knownCommit(prevPkg)
+	if prevCommit == "" {
+		return false
+	}
+
+	commit := rev
+	if !commitPrefix.MatchString(rev) {
+		var err error
+		commit, err = resolveCommit(ctx, opts.fetchTimeout, env, opts.mirrorURL(url), rev)
+		if err != nil {
+			logf("Could not resolve %s in %s: %v", rev, url, err)
+			return false
+		}
+	}
+	return strings.HasPrefix(commit, prevCommit) || strings.HasPrefix(prevCommit, commit)
+}
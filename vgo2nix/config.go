@@ -0,0 +1,96 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+)
+
+// config is the file given with --config, for modules that need special
+// treatment every run.
+type config struct {
+	// Globs of module paths left out of the output
+	Exclude []string `json:"exclude"`
+	// Known-good revs and hashes by module path, used instead of fetching
+	Override map[string]*configOverride `json:"override"`
+	// Globs of modules fetched with their .git directory or whole history
+	LeaveDotGit []string `json:"leaveDotGit"`
+	DeepClone   []string `json:"deepClone"`
+	// Globs of modules fetched without their submodules
+	NoSubmodules []string `json:"noSubmodules"`
+	// Repositories by import path prefix, as with --repo-mapping
+	Repos map[string]string `json:"repos"`
+}
+
+type configOverride struct {
+	Rev    string `json:"rev"`
+	Sha256 string `json:"sha256"`
+}
+
+// loadConfig reads a config file. Unknown keys are rejected so a typo does
+// not silently turn into a fetch.
+func loadConfig(filePath string) (*config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("Failed reading %s: %v", filePath, err)
+	}
+
+	for key, globs := range map[string][]string{"exclude": c.Exclude, "leaveDotGit": c.LeaveDotGit, "deepClone": c.DeepClone, "noSubmodules": c.NoSubmodules} {
+		for _, glob := range globs {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("Invalid %s glob \"%s\" in %s", key, glob, filePath)
+			}
+		}
+	}
+	for modulePath, override := range c.Override {
+		if override == nil || override.Rev == "" || override.Sha256 == "" {
+			return nil, fmt.Errorf("Override of %s in %s needs both rev and sha256", modulePath, filePath)
+		}
+	}
+
+	if _, err := parseRepoMappings(c.repoMappings()); err != nil {
+		return nil, fmt.Errorf("%v in %s", err, filePath)
+	}
+
+	return &c, nil
+}
+
+// repoMappings returns the repos as prefix=url pairs
+func (c *config) repoMappings() []string {
+	var pairs []string
+	for prefix, url := range c.Repos {
+		pairs = append(pairs, prefix+"="+url)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// excludeModules drops the entries whose module path matches an exclude glob
+func excludeModules(entries []*modEntry, exclude []string) []*modEntry {
+	var kept []*modEntry
+	for _, entry := range entries {
+		excluded := false
+		for _, glob := range exclude {
+			if ok, _ := path.Match(glob, entry.importPath); ok {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			logf("Excluding %s", entry.importPath)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
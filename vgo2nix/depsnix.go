@@ -0,0 +1,446 @@
+package vgo2nix
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/orivej/go-nix/nix/eval"
+	"github.com/orivej/go-nix/nix/parser"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func loadDepsNix(filePath string) map[string]*Package {
+	ret := make(map[string]*Package)
+
+	var data []byte
+	var err error
+	if filePath == stdioPath {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filePath)
+	}
+	if err != nil || len(data) == 0 {
+		return ret
+	}
+
+	// deps.json files of older nixpkgs versions
+	if json.Valid(data) {
+		deps, err := loadGoDeps(data)
+		if err != nil {
+			log.Println("Failed reading deps.json")
+			return ret
+		}
+		return deps
+	}
+
+	p, err := parser.ParseString(string(data))
+	if err != nil {
+		log.Println("Failed reading deps.nix")
+		return ret
+	}
+
+	result, bindings := letBindings(p)
+	evalResult := result.Eval()
+	for _, pkgAttrsExpr := range evalResult.(eval.List) {
+		pkgAttrs, ok := pkgAttrsExpr.Eval().(eval.Set)
+		if !ok {
+			continue
+		}
+		fetchExpr, ok := pkgAttrs[eval.Intern("fetch")]
+		if !ok {
+			continue
+		}
+		if fetchExpr.Node.Type == parser.IDNode {
+			if fetchExpr, ok = bindings[p.TokenString(fetchExpr.Node.Tokens[0])]; !ok {
+				continue
+			}
+		}
+		fetch, ok := fetchExpr.Eval().(eval.Set)
+		if !ok {
+			continue
+		}
+
+		goPackagePath, ok := evalString(pkgAttrs, "goPackagePath")
+		if !ok {
+			continue
+		}
+
+		rev, ok := evalString(fetch, "rev")
+		if !ok {
+			continue
+		}
+
+		fetcher := fetcherFetchgit
+		url, ok := evalString(fetch, "url")
+		switch fetchType, _ := evalString(fetch, "type"); fetchType {
+		case "FromGitHub":
+			owner, ownerOk := evalString(fetch, "owner")
+			repo, repoOk := evalString(fetch, "repo")
+			ok = ownerOk && repoOk
+			url = fmt.Sprintf("https://github.com/%s/%s", owner, repo)
+			fetcher = fetcherGitHub
+		case "hg":
+			fetcher = fetcherFetchhg
+		case "bzr":
+			fetcher = fetcherFetchbzr
+		case "zip":
+			fetcher = fetcherProxy
+		}
+		if !ok {
+			continue
+		}
+
+		// Entries written for fetchTree carry an SRI narHash instead, and
+		// entries written with --sri an SRI hash
+		sha256, ok := evalString(fetch, "sha256")
+		if hash, sri := evalString(fetch, "hash"); !ok && sri {
+			sha256, err = base32Hash(hash)
+			if err != nil {
+				continue
+			}
+		} else if !ok {
+			narHash, ok := evalString(fetch, "narHash")
+			if !ok {
+				continue
+			}
+			sha256, err = base32Hash(narHash)
+			if err != nil {
+				continue
+			}
+			fetcher = fetcherFetchTree
+		}
+
+		date, _ := evalString(pkgAttrs, "date")
+		// fetchLFS, leaveDotGit and deepClone are only ever written as true and
+		// fetchSubmodules as false, and go-nix cannot evaluate booleans
+		_, fetchLFS := fetch[eval.Intern("fetchLFS")]
+		_, leaveDotGit := fetch[eval.Intern("leaveDotGit")]
+		_, deepClone := fetch[eval.Intern("deepClone")]
+		_, noSubmodules := fetch[eval.Intern("fetchSubmodules")]
+		branchName, _ := evalString(fetch, "branchName")
+		// Only ever written with a single directory
+		var sparseCheckout string
+		if dirs := evalStrings(fetch, "sparseCheckout"); len(dirs) == 1 {
+			sparseCheckout = dirs[0]
+		}
+		commit, _ := evalString(fetch, "commit")
+
+		putPrevDep(ret, &Package{
+			GoPackagePath:  goPackagePath,
+			URL:            url,
+			Rev:            rev,
+			Sha256:         sha256,
+			Fetcher:        fetcher,
+			FetchLFS:       fetchLFS,
+			LeaveDotGit:    leaveDotGit,
+			DeepClone:      deepClone,
+			NoSubmodules:   noSubmodules,
+			BranchName:     branchName,
+			SparseCheckout: sparseCheckout,
+			Commit:         commit,
+			Date:           date,
+		})
+	}
+
+	for goPackagePath, comments := range entryComments(data) {
+		if pkg, ok := ret[goPackagePath]; ok {
+			pkg.Comments = comments
+		}
+	}
+
+	return ret
+}
+
+// entryComments returns the comment lines of every entry of a deps.nix by
+// goPackagePath, the ones above an entry as well as the ones inside of it.
+// The parser drops comments, so the file is scanned line by line, counting
+// braces to tell where entries end.
+func entryComments(data []byte) map[string][]string {
+	comments := make(map[string][]string)
+	var pending []string
+	goPackagePath := ""
+	depth := 0
+	header := false
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			// The header and its metadata are written anew
+			header = i == 0 && strings.HasPrefix(line, "# file generated") || header && headerMetadataLine.MatchString(line)
+			if !header {
+				pending = append(pending, line)
+			}
+			continue
+		}
+		header = false
+		if m := goPackagePathAttr.FindStringSubmatch(line); m != nil && depth == 1 {
+			goPackagePath = unescapeNixString(m[1])
+		}
+		// Braces in strings neither open nor close anything
+		code := nixStringLiteral.ReplaceAllString(line, `""`)
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth == 0 && strings.Contains(code, "}") {
+			// Comments inside a let binding belong to no entry
+			if goPackagePath != "" && len(pending) > 0 {
+				comments[goPackagePath] = pending
+			}
+			pending = nil
+			goPackagePath = ""
+		}
+	}
+	return comments
+}
+
+var (
+	nixStringLiteral  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	goPackagePathAttr = regexp.MustCompile(`^goPackagePath\s*=\s*"((?:[^"\\]|\\.)*)";`)
+)
+
+// keepComments carries the comments of the input file over to the entries
+// that are still there, fetched again or not.
+func keepComments(packages []*Package, prevDeps map[string]*Package) {
+	for _, pkg := range packages {
+		if prevPkg, ok := prevDeps[pkg.GoPackagePath]; ok && pkg.Comments == nil {
+			pkg.Comments = prevPkg.Comments
+		}
+	}
+}
+
+// putPrevDep adds an entry of the input file to prevDeps. Of several entries
+// for the same path the last one wins, as a hand edited file may well have
+// them, but not silently.
+func putPrevDep(prevDeps map[string]*Package, pkg *Package) {
+	if prevPkg, ok := prevDeps[pkg.GoPackagePath]; ok {
+		logf("Warning: the input file has more than one entry for %s (revs %s and %s), using the last one", pkg.GoPackagePath, prevPkg.Rev, pkg.Rev)
+	}
+	prevDeps[pkg.GoPackagePath] = pkg
+}
+
+// letBindings returns the body of a top level let along with its bindings.
+// go-nix cannot evaluate variables, so references to the bindings, as written
+// by --dedupe-output, have to be resolved by the caller.
+func letBindings(p *parser.Parser) (*eval.Expression, map[string]*eval.Expression) {
+	root := &eval.Expression{Parser: p, Node: p.Result}
+	bindings := make(map[string]*eval.Expression)
+	if p.Result.Type != parser.LetNode {
+		return root, bindings
+	}
+
+	for _, bind := range p.Result.Nodes[0].Nodes {
+		if bind.Type != parser.BindNode || len(bind.Nodes[0].Nodes) != 1 {
+			continue
+		}
+		name := bind.Nodes[0].Nodes[0]
+		if name.Type != parser.IDNode {
+			continue
+		}
+		bindings[p.TokenString(name.Tokens[0])] = root.WithNode(bind.Nodes[1])
+	}
+	return root.WithNode(p.Result.Nodes[1]), bindings
+}
+
+// evalString returns the value of a string attribute. go-nix leaves the
+// escapes in strings as they are.
+func evalString(set eval.Set, name string) (string, bool) {
+	expr, ok := set[eval.Intern(name)]
+	if !ok {
+		return "", false
+	}
+	s, ok := expr.Eval().(string)
+	return unescapeNixString(s), ok
+}
+
+// evalStrings returns the strings of a list attribute
+func evalStrings(set eval.Set, name string) []string {
+	expr, ok := set[eval.Intern(name)]
+	if !ok {
+		return nil
+	}
+	list, ok := expr.Eval().(eval.List)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, elem := range list {
+		if s, ok := elem.Eval().(string); ok {
+			values = append(values, unescapeNixString(s))
+		}
+	}
+	return values
+}
+
+// nixStringEscaper escapes what would end a Nix string or start an
+// interpolation in it
+var nixStringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"${", `\${`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// nixString quotes s as a Nix string
+func nixString(s string) string {
+	return `"` + nixStringEscaper.Replace(s) + `"`
+}
+
+// unescapeNixString undoes the escapes of a Nix string as written by
+// nixString
+func unescapeNixString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// formatFetch renders the fetch attribute set of a package, with its
+// attributes indented one level deeper than indent.
+func formatFetch(pkg *Package, indent string, sri bool) (string, error) {
+	var b strings.Builder
+	attr := func(name string, value string) {
+		fmt.Fprintf(&b, "%s  %s = %s;\n", indent, name, nixString(value))
+	}
+
+	b.WriteString("{\n")
+	attr("type", fetchType(pkg.Fetcher))
+	if owner, repo, ok := githubRepo(pkg.URL); ok && pkg.Fetcher == fetcherGitHub {
+		attr("owner", owner)
+		attr("repo", repo)
+	} else {
+		attr("url", pkg.URL)
+	}
+	attr("rev", pkg.Rev)
+	if pkg.Commit != "" && pkg.Commit != pkg.Rev {
+		attr("commit", pkg.Commit)
+	}
+	if subdir := proxySubdir(pkg.GoPackagePath, pkg.Rev); subdir != "" && pkg.Fetcher == fetcherProxy {
+		attr("subdir", subdir)
+	}
+	if pkg.BranchName != "" {
+		attr("branchName", pkg.BranchName)
+	}
+	if pkg.Fetcher == fetcherFetchTree {
+		narHash, err := sriHash(pkg.Sha256)
+		if err != nil {
+			return "", err
+		}
+		attr("narHash", narHash)
+	} else if sri {
+		hash, err := sriHash(pkg.Sha256)
+		if err != nil {
+			return "", err
+		}
+		attr("hash", hash)
+	} else {
+		attr("sha256", pkg.Sha256)
+	}
+	if pkg.FetchLFS {
+		fmt.Fprintf(&b, "%s  fetchLFS = true;\n", indent)
+	}
+	if pkg.LeaveDotGit {
+		fmt.Fprintf(&b, "%s  leaveDotGit = true;\n", indent)
+	}
+	if pkg.DeepClone {
+		fmt.Fprintf(&b, "%s  deepClone = true;\n", indent)
+	}
+	if pkg.NoSubmodules {
+		fmt.Fprintf(&b, "%s  fetchSubmodules = false;\n", indent)
+	}
+	if pkg.SparseCheckout != "" {
+		fmt.Fprintf(&b, "%s  sparseCheckout = [ %s ];\n", indent, nixString(pkg.SparseCheckout))
+	}
+	b.WriteString(indent + "}")
+
+	return b.String(), nil
+}
+
+func writeDepsNix(filePath string, packages []*Package, opts *options) error {
+	if opts.sortBy == sortURL {
+		packages = sortPackagesByURL(packages)
+	}
+	if opts.format == formatJSON {
+		return writeGoDeps(filePath, packages, opts)
+	}
+
+	var err error
+	fetches := make([]string, len(packages))
+	uses := make(map[string]int)
+	for i, pkg := range packages {
+		fetches[i], err = formatFetch(pkg, "    ", opts.sri)
+		if err != nil {
+			return err
+		}
+		uses[fetches[i]]++
+	}
+
+	lines := []string{
+		"# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)",
+	}
+	lines = append(lines, opts.headerMetadata...)
+
+	// Fetches shared by several entries are bound once with let
+	if opts.dedupe {
+		bindings := make(map[string]string)
+		for i, fetch := range fetches {
+			if uses[fetch] < 2 {
+				continue
+			}
+			name, ok := bindings[fetch]
+			if !ok {
+				name = fmt.Sprintf("fetch%d", len(bindings))
+				bindings[fetch] = name
+				shared, err := formatFetch(packages[i], "  ", opts.sri)
+				if err != nil {
+					return err
+				}
+				if len(bindings) == 1 {
+					lines = append(lines, "let")
+				}
+				lines = append(lines, fmt.Sprintf("  %s = %s;", name, shared))
+			}
+			fetches[i] = name
+		}
+		if len(bindings) > 0 {
+			lines = append(lines, "in")
+		}
+	}
+
+	lines = append(lines, "[")
+	for i, pkg := range packages {
+		entry, err := formatPackage(pkg, fetches[i], opts)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, entry)
+	}
+	lines = append(lines, "]")
+
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	if !opts.validate {
+		return writeFileAtomic(filePath, data)
+	}
+	return writeFileChecked(filePath, data, func(tmpPath string) error {
+		return validateNix(tmpPath, filePath)
+	})
+}
@@ -0,0 +1,33 @@
+package vgo2nix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNixStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		s      string
+		quoted string
+	}{
+		{"https://github.com/example/dep", `"https://github.com/example/dep"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\go\src`, `"C:\\go\\src"`},
+		{"${builtins.currentSystem}", `"\${builtins.currentSystem}"`},
+		{"$HOME and $", `"$HOME and $"`},
+		{`\${`, `"\\\${"`},
+		{`"\${}"`, `"\"\\\${}\""`},
+		{"line\nnext\ttab\r", `"line\nnext\ttab\r"`},
+	}
+
+	for _, test := range tests {
+		quoted := nixString(test.s)
+		if quoted != test.quoted {
+			t.Errorf("nixString(%q) = %s, expected %s", test.s, quoted, test.quoted)
+		}
+		unquoted := strings.TrimSuffix(strings.TrimPrefix(quoted, `"`), `"`)
+		if s := unescapeNixString(unquoted); s != test.s {
+			t.Errorf("unescapeNixString(%s) = %q, expected %q", unquoted, s, test.s)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package vgo2nix
+
+import (
+	"context"
+	"strings"
+)
+
+// runtimeModules returns the paths of the modules needed to build the
+// packages of the main modules in all directories. Imports of test files and
+// of files behind build tags, like the tools.go convention for build tools,
+// are left out, so the modules needed only for them are missing.
+func runtimeModules(ctx context.Context, opts *options) (map[string]bool, error) {
+	runtime := make(map[string]bool)
+	for _, dir := range opts.moduleDirs() {
+		dirOpts := *opts
+		dirOpts.dir = dir
+		goBinary, goEnv, err := dirOpts.goToolchain()
+		if err != nil {
+			return nil, err
+		}
+		modules, err := packageModules(ctx, dir, goBinary, goEnv, opts.modMode, "./...")
+		if err != nil {
+			return nil, err
+		}
+		for path := range modules {
+			runtime[path] = true
+		}
+	}
+	return runtime, nil
+}
+
+// splitDevPackages parts packages into the ones needed at runtime and the
+// ones only needed for development. A package whose repository holds any
+// runtime module counts as runtime, so a shared entry is never left out.
+func splitDevPackages(packages []*Package, runtime map[string]bool) (runtimePackages []*Package, devPackages []*Package) {
+	for _, pkg := range packages {
+		if isRuntimePackage(pkg, runtime) {
+			runtimePackages = append(runtimePackages, pkg)
+		} else {
+			devPackages = append(devPackages, pkg)
+		}
+	}
+	return runtimePackages, devPackages
+}
+
+func isRuntimePackage(pkg *Package, runtime map[string]bool) bool {
+	if runtime[pkg.ModulePath] {
+		return true
+	}
+	for path := range runtime {
+		if path == pkg.GoPackagePath || strings.HasPrefix(path, pkg.GoPackagePath+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package vgo2nix
+
+type packageUpdate struct {
+	Old *Package
+	New *Package
+}
+
+// depsDiff describes how a set of packages differs from a previous deps.nix
+type depsDiff struct {
+	Added   []*Package
+	Removed []*Package
+	Updated []*packageUpdate
+}
+
+func (d *depsDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// diffPackages compares packages keyed on GoPackagePath against prevDeps. A
+// package counts as updated if either its rev or its hash changed.
+func diffPackages(prevDeps map[string]*Package, packages []*Package) *depsDiff {
+	diff := &depsDiff{}
+
+	seen := make(map[string]bool)
+	for _, pkg := range packages {
+		seen[pkg.GoPackagePath] = true
+		prevPkg, ok := prevDeps[pkg.GoPackagePath]
+		if !ok {
+			diff.Added = append(diff.Added, pkg)
+			continue
+		}
+		if prevPkg.Rev != pkg.Rev || prevPkg.Sha256 != pkg.Sha256 {
+			diff.Updated = append(diff.Updated, &packageUpdate{
+				Old: prevPkg,
+				New: pkg,
+			})
+		}
+	}
+
+	removed := make(map[string]*Package)
+	for goPackagePath, prevPkg := range prevDeps {
+		if !seen[goPackagePath] {
+			removed[goPackagePath] = prevPkg
+		}
+	}
+	diff.Removed = sortPackages(removed)
+
+	return diff
+}
+
+// log prints the differences, rev and hash changes of a package on lines of
+// their own, so that a package fetched again at the same rev only shows a
+// changed sha256. Packages a dry run would fetch have no hash yet.
+func (d *depsDiff) log() {
+	if d.empty() {
+		logf("No changes")
+		return
+	}
+	for _, pkg := range d.Added {
+		logf("Added %s %s", pkg.GoPackagePath, pkg.Rev)
+	}
+	for _, pkg := range d.Removed {
+		logf("Removed %s %s", pkg.GoPackagePath, pkg.Rev)
+	}
+	for _, update := range d.Updated {
+		if update.Old.Rev != update.New.Rev {
+			logf("Updated %s: rev %s -> %s", update.New.GoPackagePath, update.Old.Rev, update.New.Rev)
+		}
+		if update.New.Sha256 != "" && update.Old.Sha256 != update.New.Sha256 {
+			logf("Updated %s: sha256 %s -> %s", update.New.GoPackagePath, update.Old.Sha256, update.New.Sha256)
+		}
+	}
+}
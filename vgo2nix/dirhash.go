@@ -0,0 +1,173 @@
+package vgo2nix
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var majorVersionDir = regexp.MustCompile(`^(?:(.*)/)?v[0-9]+$`)
+
+// moduleDir returns the directory of a module in a checkout of the repository
+// at goPackagePath. Like the go command a /vN module is looked up in the vN
+// subdirectory first and in its parent if that has no go.mod.
+func moduleDir(root string, goPackagePath string, modulePath string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(modulePath, goPackagePath), "/")
+	if m := majorVersionDir.FindStringSubmatch(rel); m != nil {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel), "go.mod")); err != nil {
+			rel = m[1]
+		}
+	}
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
+
+// gitSubmodulePaths returns the paths of the submodules declared in the
+// .gitmodules file of a checkout.
+func gitSubmodulePaths(root string) map[string]bool {
+	paths := make(map[string]bool)
+	f, err := os.Open(filepath.Join(root, ".gitmodules"))
+	if err != nil {
+		return paths
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == "path" {
+			paths[strings.TrimSpace(parts[1])] = true
+		}
+	}
+	return paths
+}
+
+// isVendoredPackage mirrors the go command, including its offset bug for
+// nested vendor directories that cannot be fixed without changing hashes.
+func isVendoredPackage(name string) bool {
+	var i int
+	if strings.HasPrefix(name, "vendor/") {
+		i += len("vendor/")
+	} else if j := strings.Index(name, "/vendor/"); j >= 0 {
+		i += len("/vendor/")
+	} else {
+		return false
+	}
+	return strings.Contains(name[i:], "/")
+}
+
+// moduleFiles lists the files the go command puts into the zip of the module
+// in dir, a directory of the checkout at root, by their path in the module.
+// Submodules are left out as the go command does not fetch them.
+func moduleFiles(root string, dir string) (map[string]string, error) {
+	submodules := gitSubmodulePaths(root)
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		repoRel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if file == dir {
+				return nil
+			}
+			switch info.Name() {
+			case ".bzr", ".git", ".hg", ".svn":
+				return filepath.SkipDir
+			}
+			if submodules[filepath.ToSlash(repoRel)] {
+				return filepath.SkipDir
+			}
+			// Nested modules are modules of their own
+			if _, err := os.Stat(filepath.Join(file, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() || isVendoredPackage(rel) {
+			return nil
+		}
+		files[rel] = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Modules in a subdirectory get the license of the repository
+	if _, ok := files["LICENSE"]; !ok && dir != root {
+		license := filepath.Join(root, "LICENSE")
+		if info, err := os.Lstat(license); err == nil && info.Mode().IsRegular() {
+			files["LICENSE"] = license
+		}
+	}
+
+	return files, nil
+}
+
+// moduleHash computes the h1: hash go.sum records for the module in dir,
+// which is the sha256 of a sorted list of the sha256 of every file.
+func moduleHash(root string, dir string, modulePath string, version string) (string, error) {
+	files, err := moduleFiles(root, dir)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summary := sha256.New()
+	for _, name := range names {
+		f, err := os.Open(files[name])
+		if err != nil {
+			return "", err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), path.Join(modulePath+"@"+version, name))
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+// verifyGoSum checks the checkout of a module at root against go.sum.
+// Modules without a hash of their tree in go.sum are not verified.
+func verifyGoSum(sums map[string]string, root string, goPackagePath string, modulePath string, version string) error {
+	want, ok := sums[modulePath+"@"+version]
+	if !ok {
+		logf("Not verifying %s@%s, go.sum has no hash of its tree", modulePath, version)
+		return nil
+	}
+
+	got, err := moduleHash(root, moduleDir(root, goPackagePath, modulePath), modulePath, version)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("Checkout of %s@%s has hash %s, but go.sum has %s", modulePath, version, got, want)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package vgo2nix
+
+import (
+	"path"
+)
+
+// keepsDotGit reports whether the .git directory of a module is left in its
+// checkout, for modules that read their commit at build time. A deep clone
+// always keeps it, as fetchgit does.
+func (opts *options) keepsDotGit(modulePath string, goPackagePath string) bool {
+	return opts.leaveDotGit || matchesModule(opts.leaveDotGitGlobs, modulePath, goPackagePath) || opts.deepClones(modulePath, goPackagePath)
+}
+
+// deepClones reports whether the whole history of a module is fetched
+func (opts *options) deepClones(modulePath string, goPackagePath string) bool {
+	return opts.deepClone || matchesModule(opts.deepCloneGlobs, modulePath, goPackagePath)
+}
+
+// matchesModule reports whether a glob matches either the module path or the
+// goPackagePath of a module.
+func matchesModule(globs []string, modulePath string, goPackagePath string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, modulePath); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, goPackagePath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// dotGitCacheFetcher extends the fetcher hashes are cached under, the .git
+// directory and with a deep clone all of history are part of the hash.
+func dotGitCacheFetcher(cacheFetcher string, leaveDotGit bool, deepClone bool) string {
+	if deepClone {
+		return cacheFetcher + "+deep"
+	}
+	if leaveDotGit {
+		return cacheFetcher + "+dotgit"
+	}
+	return cacheFetcher
+}
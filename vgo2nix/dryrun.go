@@ -0,0 +1,48 @@
+package vgo2nix
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// exitWouldFetch is the exit status of --dry-run when anything would have to
+// be fetched.
+const exitWouldFetch = 4
+
+// wouldFetchError is the result of modules that would be fetched in a dry run
+type wouldFetchError struct {
+	goPackagePath string
+	rev           string
+}
+
+func (e *wouldFetchError) Error() string {
+	return fmt.Sprintf("%s would be fetched at %s", e.goPackagePath, e.rev)
+}
+
+// dryRunSummary logs which modules are reused and which would be fetched and
+// returns the ones that would be fetched, without hashes, and the failures
+// that are not just dry run fetches.
+func dryRunSummary(packages []*Package, failed []*PackageResult) ([]*Package, []*PackageResult) {
+	var fetches []*wouldFetchError
+	var rest []*PackageResult
+	for _, result := range failed {
+		var fetch *wouldFetchError
+		if errors.As(result.Error, &fetch) {
+			fetches = append(fetches, fetch)
+		} else {
+			rest = append(rest, result)
+		}
+	}
+	sort.Slice(fetches, func(i, j int) bool {
+		return fetches[i].goPackagePath < fetches[j].goPackagePath
+	})
+
+	logf("Reusing %d modules, %d to fetch", len(packages), len(fetches))
+	var fetched []*Package
+	for _, fetch := range fetches {
+		logf("  %s %s", fetch.goPackagePath, fetch.rev)
+		fetched = append(fetched, &Package{GoPackagePath: fetch.goPackagePath, Rev: fetch.rev})
+	}
+	return fetched, rest
+}
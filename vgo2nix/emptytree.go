@@ -0,0 +1,50 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"os"
+)
+
+// emptyTreeSha256 is the hash of an empty directory, which is what
+// nix-prefetch-git reports when the checkout of a rev failed.
+const emptyTreeSha256 = "0sjjj9z1dhilhpc8pq4154czrb79z9cm044jvn75kxcjv6v5l2m5"
+
+// emptyTreeSRI is emptyTreeSha256 in the SRI form newer prefetchers print
+const emptyTreeSRI = "sha256-pQpattmS9VmO3ZIQUFn66az8GSmB4IvYhTTCFn6SUmo="
+
+// isEmptyTree reports whether a hash in either form is the empty tree
+func isEmptyTree(hash string) bool {
+	return hash == emptyTreeSha256 || hash == emptyTreeSRI
+}
+
+// emptyTreeHint explains the usual causes of an empty tree
+const emptyTreeHint = "the rev was likely not found in the repo; the tag may be on a submodule or the module path may not map to this repo root"
+
+// checkEmptyTree decides whether an empty tree reported by nix-prefetch-git is
+// genuine. That is only the case if the prefetch resolved exactly the requested
+// commit and the checkout in the store is indeed empty, everything else is
+// taken as a failed fetch.
+func checkEmptyTree(resp map[string]interface{}, rev string) error {
+	resolved, _ := resp["rev"].(string)
+	if resolved == "" {
+		return fmt.Errorf("rev could not be resolved")
+	}
+	if !revMatches(resolved, rev) {
+		return fmt.Errorf("rev resolved to %s but the checkout is empty", resolved)
+	}
+
+	path, _ := resp["path"].(string)
+	if path == "" {
+		return fmt.Errorf("no checkout in the store")
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("no checkout in the store: %v", err)
+	}
+	defer dir.Close()
+	if names, _ := dir.Readdirnames(1); len(names) > 0 {
+		return fmt.Errorf("checkout %s is not empty", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package vgo2nix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// prefetchEnv returns the environment for prefetch subprocesses. The whole
+// environment is inherited so HOME, SSH_AUTH_SOCK and any GIT_* variables reach
+// git and with them the user's credential helpers. Extra git configuration is
+// passed through GIT_CONFIG_COUNT/GIT_CONFIG_KEY_n/GIT_CONFIG_VALUE_n which git
+// reads on top of its config files.
+func prefetchEnv(gitConfig []string) ([]string, error) {
+	env := os.Environ()
+	if len(gitConfig) == 0 {
+		return env, nil
+	}
+
+	count := 0
+	if existing := os.Getenv("GIT_CONFIG_COUNT"); existing != "" {
+		var err error
+		count, err = strconv.Atoi(existing)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid GIT_CONFIG_COUNT \"%s\"", existing)
+		}
+	}
+
+	for _, kv := range gitConfig {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid git config \"%s\", expected key=value", kv)
+		}
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, parts[0]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, parts[1]))
+		count++
+	}
+
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", count)), nil
+}
+
+// goEnvVars returns the values of go environment variables as goBinary
+// prints them. Without a go binary, e.g. with --modules-json in a sandbox,
+// they are taken from the environment alone.
+func goEnvVars(goBinary string, names ...string) ([]string, error) {
+	out, err := exec.Command(goBinary, append([]string{"env"}, names...)...).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = os.Getenv(name)
+		}
+		return values, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n"), nil
+}
+
+// hasEnvVar reports whether env sets the variable name
+func hasEnvVar(env []string, name string) bool {
+	for _, v := range env {
+		if strings.HasPrefix(v, name+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// failureReason is what went wrong with a failed module in a line: the kind
+// of a failed fetch with the last line git printed, or else the first line
+// of the error without the import path it is about.
+func failureReason(result *PackageResult) string {
+	var prefetchErr *prefetchError
+	if errors.As(result.Error, &prefetchErr) {
+		if prefetchErr.detail == "" {
+			return prefetchErr.kind.String()
+		}
+		return fmt.Sprintf("%s: %s", prefetchErr.kind, prefetchErr.detail)
+	}
+	msg := strings.TrimPrefix(result.Error.Error(), fmt.Sprintf("Error processing import path \"%s\": ", result.ImportPath))
+	return strings.SplitN(msg, "\n", 2)[0]
+}
+
+func sortFailures(failed []*PackageResult) []*PackageResult {
+	sorted := append([]*PackageResult(nil), failed...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ImportPath < sorted[j].ImportPath
+	})
+	return sorted
+}
+
+// logFailures sums up the failed modules at the end of a run, which are
+// easily missed among the messages of the fetches
+func logFailures(failed []*PackageResult) {
+	logf("Failed modules:")
+	for _, result := range sortFailures(failed) {
+		reason := failureReason(result)
+		logEventf(&logEvent{Event: "failed", Path: result.ImportPath, Message: reason}, "  %s: %s", result.ImportPath, reason)
+	}
+}
+
+// writeErrorLog writes the full errors of the failed modules to filePath,
+// each below its import path and followed by all the prefetcher printed to
+// stderr, of which the error only has the last line
+func writeErrorLog(filePath string, failed []*PackageResult) error {
+	var buf bytes.Buffer
+	writeIndented := func(indent, text string) {
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			fmt.Fprintf(&buf, "%s%s\n", indent, line)
+		}
+	}
+	for i, result := range sortFailures(failed) {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "%s:\n", result.ImportPath)
+		writeIndented("  ", result.Error.Error())
+		var exitErr *exec.ExitError
+		if errors.As(result.Error, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+			buf.WriteString("  stderr:\n")
+			writeIndented("    ", string(exitErr.Stderr))
+		}
+	}
+	return writeFileAtomic(filePath, buf.Bytes())
+}
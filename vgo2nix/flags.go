@@ -0,0 +1,38 @@
+package vgo2nix
+
+import (
+	"flag"
+	"strings"
+)
+
+// stringList is a flag that can be given multiple times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// splitList splits a comma separated flag value, ignoring empty elements
+func splitList(value string) []string {
+	var ret []string
+	for _, elem := range strings.Split(value, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			ret = append(ret, elem)
+		}
+	}
+	return ret
+}
+
+// flagSet reports whether the flag called name was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
@@ -0,0 +1,119 @@
+package vgo2nix
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Options of Generate. The zero value generates entries for the module in the
+// current directory with fetchgit, fetching 20 modules at a time.
+type Options struct {
+	// Directory of the Go module, empty for the current one
+	Dir string
+	// Leave out modules that fail to fetch rather than failing
+	KeepGoing bool
+	// Number of parallel fetches, 20 if zero
+	Jobs int
+	// Entries of a previous deps.nix whose hashes are reused, see LoadDepsNix
+	PrevDeps map[string]*Package
+	// Fetcher to generate entries for: fetchgit (the default), fetchtree or github
+	Fetcher string
+	// Receives the progress messages, which are discarded if nil
+	Log io.Writer
+	// Computes the hashes instead of nix-prefetch-git and nix-prefetch-url if
+	// set, e.g. a fake in tests. Not supported by the fetchtree fetcher, which
+	// needs the commit every rev resolves to.
+	Prefetcher Prefetcher
+}
+
+// Prefetcher computes the hash Nix gives to the checkout of a repository at
+// a rev, the base32 sha256 of nix-prefetch-git or an SRI hash. Failures
+// whose message nix-prefetch-git would print, such as a missing remote ref,
+// are handled like those of nix-prefetch-git. Without a Prefetcher the
+// command of PrefetchCommand is run.
+type Prefetcher interface {
+	Fetch(req PrefetchRequest) (sha256 string, err error)
+}
+
+// PrefetchRequest is a checkout to hash along with the options of the fetch
+// the entry is written with, which the hash has to match
+type PrefetchRequest struct {
+	// Fetcher the entry is written for, e.g. fetchgit or github
+	Fetcher string
+	// The repository, or the tarball for the github fetcher
+	URL string
+	Rev string
+	// Options of fetchgit
+	FetchSubmodules bool
+	FetchLFS        bool
+	LeaveDotGit     bool
+	DeepClone       bool
+	BranchName      string
+	// The directory of the repository checked out alone, empty for all of it
+	SparseCheckout string
+}
+
+// Generate returns the deps.nix entries of all modules the module in
+// opts.Dir depends on, fetching the ones whose hash is not known from
+// opts.PrevDeps. Progress goes to a process wide writer, which is restored
+// when Generate returns, so Generate must not be called concurrently.
+func Generate(ctx context.Context, opts Options) ([]*Package, error) {
+	jobs := opts.Jobs
+	if jobs == 0 {
+		jobs = 20
+	} else if jobs < 0 {
+		return nil, fmt.Errorf("Jobs must be at least 1, got %d", jobs)
+	}
+
+	fetcher := opts.Fetcher
+	switch fetcher {
+	case "":
+		fetcher = fetcherFetchgit
+	case fetcherFetchgit, fetcherFetchTree, fetcherGitHub:
+	default:
+		return nil, fmt.Errorf("Unknown fetcher \"%s\"", opts.Fetcher)
+	}
+
+	if opts.Prefetcher != nil && fetcher == fetcherFetchTree {
+		return nil, fmt.Errorf("A Prefetcher cannot be used with the %s fetcher", fetcherFetchTree)
+	}
+
+	defer func(prev io.Writer) { logOutput = prev }(logOutput)
+	logOutput = io.Discard
+	if opts.Log != nil {
+		logOutput = opts.Log
+	}
+
+	prevDeps := opts.PrevDeps
+	if prevDeps == nil {
+		prevDeps = make(map[string]*Package)
+	}
+
+	packages, _, err := getPackages(ctx, &options{
+		dir:        opts.Dir,
+		keepGoing:  opts.KeepGoing,
+		numJobs:    jobs,
+		fetcher:    fetcher,
+		format:     formatNix,
+		prefetcher: opts.Prefetcher,
+		repoRoots:  newRepoRootCache(),
+	}, prevDeps)
+	if err != nil {
+		return nil, err
+	}
+	keepComments(packages, prevDeps)
+	return packages, nil
+}
+
+// LoadDepsNix reads the entries of a deps.nix file, or of the deps.json of
+// older nixpkgs versions, for Options.PrevDeps. A missing or unreadable file
+// has no entries.
+func LoadDepsNix(filePath string) map[string]*Package {
+	return loadDepsNix(filePath)
+}
+
+// WriteDepsNix writes packages to filePath in the deps.nix format
+func WriteDepsNix(filePath string, packages []*Package) error {
+	return writeDepsNix(filePath, packages, &options{format: formatNix})
+}
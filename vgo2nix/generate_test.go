@@ -0,0 +1,278 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withFakeGo puts a go in front of PATH that lists modules
+func withFakeGo(t *testing.T, modules string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go is a shell script")
+	}
+	bin := t.TempDir()
+	script := fmt.Sprintf(`#!/bin/sh
+case "$1" in
+env) echo ;;
+list) cat <<'EOF'
+%sEOF
+;;
+*) echo "unexpected go $*" >&2; exit 1 ;;
+esac
+`, modules)
+	if err := os.WriteFile(filepath.Join(bin, "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+	t.Cleanup(func() { os.Setenv("PATH", path) })
+}
+
+func TestGenerateRestoresLog(t *testing.T) {
+	withFakeGo(t, `{"Path": "github.com/example/main", "Main": true}
+`)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/example/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := logOutput
+	defer func() { logOutput = prev }()
+	var before bytes.Buffer
+	logOutput = &before
+
+	var log bytes.Buffer
+	if _, err := Generate(context.Background(), Options{Dir: dir, Log: &log}); err != nil {
+		t.Fatal(err)
+	}
+	if logOutput != &before {
+		t.Errorf("Generate left the log output at %v", logOutput)
+	}
+	if before.Len() != 0 {
+		t.Errorf("logged %q to the previous output, expected all to Options.Log", before.String())
+	}
+}
+
+// fakeGoModules is what the fake go prints for go list -json -m all
+const fakeGoModules = `{
+	"Path": "github.com/example/main",
+	"Main": true
+}
+{
+	"Path": "github.com/example/dep",
+	"Version": "v1.0.0"
+}
+{
+	"Path": "github.com/example/pseudo",
+	"Version": "v0.0.0-20200101000000-0123456789ab"
+}
+`
+
+// stubHashes are the hashes stubPrefetcher gives the checkouts of
+// fakeGoModules
+var stubHashes = map[string]string{
+	"https://github.com/example/dep@v1.0.0":          "0aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	"https://github.com/example/pseudo@0123456789ab": "0bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+}
+
+// stubPrefetcher looks the hashes of checkouts up in stubHashes
+type stubPrefetcher struct {
+	mu       sync.Mutex
+	fetched  []string
+	requests []PrefetchRequest
+}
+
+func (p *stubPrefetcher) Fetch(req PrefetchRequest) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetched = append(p.fetched, req.URL+"@"+req.Rev)
+	p.requests = append(p.requests, req)
+	if hash, ok := stubHashes[req.URL+"@"+req.Rev]; ok {
+		return hash, nil
+	}
+	return "", fmt.Errorf("fatal: couldn't find remote ref %s", req.Rev)
+}
+
+func TestGenerate(t *testing.T) {
+	withFakeGo(t, fakeGoModules)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/example/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prefetcher := &stubPrefetcher{}
+	packages, err := Generate(context.Background(), Options{
+		Dir:        dir,
+		Jobs:       1,
+		Prefetcher: prefetcher,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Package{
+		{
+			GoPackagePath: "github.com/example/dep",
+			URL:           "https://github.com/example/dep",
+			Rev:           "v1.0.0",
+			Sha256:        stubHashes["https://github.com/example/dep@v1.0.0"],
+		},
+		{
+			GoPackagePath: "github.com/example/pseudo",
+			URL:           "https://github.com/example/pseudo",
+			Rev:           "0123456789ab",
+			Sha256:        stubHashes["https://github.com/example/pseudo@0123456789ab"],
+		},
+	}
+	if len(packages) != len(expected) {
+		t.Fatalf("got %d packages, expected %d: %+v", len(packages), len(expected), packages)
+	}
+	for i, pkg := range packages {
+		want := expected[i]
+		if pkg.GoPackagePath != want.GoPackagePath || pkg.URL != want.URL || pkg.Rev != want.Rev || pkg.Sha256 != want.Sha256 {
+			t.Errorf("package %d is %s %s %s %s, expected %s %s %s %s", i,
+				pkg.GoPackagePath, pkg.URL, pkg.Rev, pkg.Sha256,
+				want.GoPackagePath, want.URL, want.Rev, want.Sha256)
+		}
+	}
+	if len(prefetcher.fetched) != len(expected) {
+		t.Errorf("fetched %v, expected every module once", prefetcher.fetched)
+	}
+	for _, req := range prefetcher.requests {
+		if req.Fetcher != fetcherFetchgit || !req.FetchSubmodules {
+			t.Errorf("requested %+v, expected a fetchgit checkout with submodules", req)
+		}
+	}
+}
+
+// prefetcherFunc is a Prefetcher calling the function
+type prefetcherFunc func(req PrefetchRequest) (string, error)
+
+func (f prefetcherFunc) Fetch(req PrefetchRequest) (string, error) {
+	return f(req)
+}
+
+func TestGenerateGitHubTarball(t *testing.T) {
+	withFakeGo(t, fakeGoModules)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/example/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var urls []string
+	packages, err := Generate(context.Background(), Options{
+		Dir:     dir,
+		Fetcher: fetcherGitHub,
+		Prefetcher: prefetcherFunc(func(req PrefetchRequest) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			urls = append(urls, req.URL)
+			return stubHashes["https://github.com/example/dep@v1.0.0"], nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hash is the one of the tarball the github fetcher is written with
+	sort.Strings(urls)
+	expected := []string{
+		"https://github.com/example/dep/archive/v1.0.0.tar.gz",
+		"https://github.com/example/pseudo/archive/0123456789ab.tar.gz",
+	}
+	if strings.Join(urls, " ") != strings.Join(expected, " ") {
+		t.Errorf("fetched %v, expected %v", urls, expected)
+	}
+	if len(packages) != 2 {
+		t.Errorf("got %+v, expected both modules", packages)
+	}
+}
+
+func TestGenerateReusesPrevDeps(t *testing.T) {
+	withFakeGo(t, fakeGoModules)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/example/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prevHash := "0ccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	prefetcher := &stubPrefetcher{}
+	packages, err := Generate(context.Background(), Options{
+		Dir: dir,
+		PrevDeps: map[string]*Package{
+			"github.com/example/dep": {
+				GoPackagePath: "github.com/example/dep",
+				URL:           "https://github.com/example/dep",
+				Rev:           "v1.0.0",
+				Sha256:        prevHash,
+				Fetcher:       fetcherFetchgit,
+			},
+		},
+		Prefetcher: prefetcher,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 2 || packages[0].Sha256 != prevHash {
+		t.Errorf("expected the hash of github.com/example/dep to be reused, got %+v", packages)
+	}
+	if len(prefetcher.fetched) != 1 || prefetcher.fetched[0] != "https://github.com/example/pseudo@0123456789ab" {
+		t.Errorf("fetched %v, expected only github.com/example/pseudo", prefetcher.fetched)
+	}
+}
+
+func TestGeneratePrefetcherPanic(t *testing.T) {
+	withFakeGo(t, fakeGoModules)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/example/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A panic while processing a module is the error of that module, the
+	// worker running it must not leave Generate waiting for its result
+	prefetcher := prefetcherFunc(func(req PrefetchRequest) (string, error) {
+		if req.URL == "https://github.com/example/pseudo" {
+			panic("prefetcher bug")
+		}
+		return stubHashes[req.URL+"@"+req.Rev], nil
+	})
+	for _, keepGoing := range []bool{false, true} {
+		done := make(chan struct{})
+		var packages []*Package
+		var err error
+		go func() {
+			defer close(done)
+			packages, err = Generate(context.Background(), Options{
+				Dir:        dir,
+				Jobs:       2,
+				KeepGoing:  keepGoing,
+				Prefetcher: prefetcher,
+			})
+		}()
+		select {
+		case <-done:
+		case <-time.After(30 * time.Second):
+			t.Fatalf("Generate with KeepGoing %v did not return after a panic", keepGoing)
+		}
+
+		if keepGoing {
+			if err != nil || len(packages) != 1 || packages[0].GoPackagePath != "github.com/example/dep" {
+				t.Errorf("KeepGoing returned %+v, %v, expected only github.com/example/dep", packages, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), "github.com/example/pseudo") || !strings.Contains(err.Error(), "panic: prefetcher bug") {
+			t.Errorf("expected the panic as error of github.com/example/pseudo, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fetcherGitHub emits fetchFromGitHub entries, which fetch the tarball GitHub
+// generates for a rev instead of cloning the repository. The tarball contains
+// neither submodules nor files excluded with export-ignore, so its hash
+// differs from the one of nix-prefetch-git and is computed separately.
+const fetcherGitHub = "github"
+
+// githubRepo returns owner and repo of a GitHub repository URL
+func githubRepo(url string) (string, string, bool) {
+	path := strings.TrimPrefix(url, "https://github.com/")
+	if path == url {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimSuffix(path, ".git"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// githubArchiveURL is the tarball URL fetchFromGitHub downloads
+func githubArchiveURL(owner string, repo string, rev string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/archive/%s.tar.gz", owner, repo, rev)
+}
@@ -0,0 +1,90 @@
+package vgo2nix
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	formatNix  = "nix"
+	formatJSON = "json"
+)
+
+// goDepsEntry is an entry of the JSON goDeps files (deps.json) read by older
+// nixpkgs versions of buildGoPackage.
+type goDepsEntry struct {
+	GoPackagePath string           `json:"goPackagePath"`
+	Date          string           `json:"date,omitempty"`
+	Fetch         goDepsEntryFetch `json:"fetch"`
+}
+
+type goDepsEntryFetch struct {
+	Type       string `json:"type"`
+	URL        string `json:"url"`
+	Rev        string `json:"rev"`
+	BranchName string `json:"branchName,omitempty"`
+	Sha256     string `json:"sha256"`
+	FetchLFS   bool   `json:"fetchLFS,omitempty"`
+}
+
+func loadGoDeps(data []byte) (map[string]*Package, error) {
+	var entries []goDepsEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]*Package)
+	for _, entry := range entries {
+		fetcher := fetcherFetchgit
+		switch entry.Fetch.Type {
+		case "git":
+		case "hg":
+			fetcher = fetcherFetchhg
+		case "bzr":
+			fetcher = fetcherFetchbzr
+		default:
+			continue
+		}
+		putPrevDep(ret, &Package{
+			GoPackagePath: entry.GoPackagePath,
+			URL:           entry.Fetch.URL,
+			Rev:           entry.Fetch.Rev,
+			Sha256:        entry.Fetch.Sha256,
+			Fetcher:       fetcher,
+			FetchLFS:      entry.Fetch.FetchLFS,
+			BranchName:    entry.Fetch.BranchName,
+			Date:          entry.Date,
+		})
+	}
+	return ret, nil
+}
+
+func writeGoDeps(filePath string, packages []*Package, opts *options) error {
+	entries := []goDepsEntry{}
+	for _, pkg := range packages {
+		if pkg.Fetcher != fetcherFetchgit && pkg.Fetcher != fetcherFetchhg && pkg.Fetcher != fetcherFetchbzr {
+			return fmt.Errorf("Cannot write %s entry for %s as JSON", pkg.Fetcher, pkg.GoPackagePath)
+		}
+		entry := goDepsEntry{
+			GoPackagePath: pkg.GoPackagePath,
+			Fetch: goDepsEntryFetch{
+				Type:       fetchType(pkg.Fetcher),
+				URL:        pkg.URL,
+				Rev:        pkg.Rev,
+				BranchName: pkg.BranchName,
+				Sha256:     pkg.Sha256,
+				FetchLFS:   pkg.FetchLFS,
+			},
+		}
+		if opts.annotateDate {
+			entry.Date = pkg.Date
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, append(data, '\n'))
+}
@@ -0,0 +1,31 @@
+package vgo2nix
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// goModDirective returns the argument of the first single line directive
+// (e.g. toolchain) in a go.mod file.
+func goModDirective(filePath string, directive string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == directive {
+			return fields[1], nil
+		}
+	}
+
+	return "", scanner.Err()
+}
@@ -0,0 +1,126 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// formatGomod2nix is the gomod2nix.toml of gomod2nix's buildGoApplication,
+// which fetches the modules from the module proxy rather than from their
+// repositories.
+const formatGomod2nix = "gomod2nix"
+
+// Values of --output-format, named after the builders consuming the output
+const (
+	outputBuildGoPackage = "buildGoPackage"
+	outputBuildGoModule  = "buildGoModule"
+)
+
+// gomod2nixPackages hashes every module like gomod2nix does, which is the
+// NAR hash of the module as downloaded by go. Nothing is fetched with the
+// prefetchers, go downloads the modules into its module cache.
+func gomod2nixPackages(ctx context.Context, opts *options) ([]*Package, error) {
+	entries, err := getModules(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.allowedVersions != nil {
+		if err := checkVersionAllowlist(entries, opts.allowedVersions); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.exclude) > 0 {
+		entries = excludeModules(entries, opts.exclude)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	goBinary, goEnv, err := opts.goToolchain()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"mod", "download", "-json"}
+	for _, entry := range entries {
+		args = append(args, entry.downloadPath()+"@"+entry.version)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, goBinary, args...)
+	cmd.Dir = opts.dir
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"GO111MODULE=on",
+	)
+	cmd.Env = append(cmd.Env, goEnv...)
+	// go exits with an error if any module failed, which is reported below
+	out, _ := cmd.Output()
+
+	type download struct {
+		Path    string
+		Version string
+		Dir     string
+		Error   string
+	}
+	dirs := make(map[string]string)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var dl download
+		if err := dec.Decode(&dl); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("'go mod download' failed with %s:\n%s", err, stderr.String())
+		}
+		if dl.Error != "" {
+			return nil, fmt.Errorf("Failed downloading %s@%s: %s", dl.Path, dl.Version, dl.Error)
+		}
+		dirs[dl.Path+"@"+dl.Version] = dl.Dir
+	}
+
+	var packages []*Package
+	for _, entry := range entries {
+		dir, ok := dirs[entry.downloadPath()+"@"+entry.version]
+		if !ok || dir == "" {
+			return nil, fmt.Errorf("'go mod download' did not download %s@%s:\n%s", entry.downloadPath(), entry.version, stderr.String())
+		}
+		hash, err := narHash(dir)
+		if err != nil {
+			return nil, err
+		}
+		logEventf(&logEvent{Event: "fetch_done", Path: entry.importPath, Rev: entry.version, Sha256: nixBase32Encode(hash)}, "Hashed %s", entry.importPath)
+		packages = append(packages, &Package{
+			GoPackagePath: entry.importPath,
+			Sha256:        nixBase32Encode(hash),
+			ModulePath:    entry.importPath,
+			Version:       entry.version,
+			ReplacePath:   entry.replacePath,
+		})
+	}
+	return packages, nil
+}
+
+// writeGomod2nix writes packages as a gomod2nix.toml file
+func writeGomod2nix(filePath string, packages []*Package) error {
+	var b strings.Builder
+	b.WriteString("# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)\n")
+	b.WriteString("schema = 3\n\n[mod]\n")
+	for _, pkg := range packages {
+		hash, err := sriHash(pkg.Sha256)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "  [mod.%q]\n", pkg.ModulePath)
+		fmt.Fprintf(&b, "    version = %q\n", pkg.Version)
+		fmt.Fprintf(&b, "    hash = %q\n", hash)
+		if pkg.ReplacePath != "" {
+			fmt.Fprintf(&b, "    replaced = %q\n", pkg.ReplacePath)
+		}
+	}
+	return writeFileAtomic(filePath, []byte(b.String()))
+}
@@ -0,0 +1,104 @@
+package vgo2nix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputFormats(t *testing.T) {
+	packages := []*Package{
+		{
+			GoPackagePath: "github.com/orivej/e",
+			URL:           "https://github.com/orivej/e",
+			Rev:           "ac3492690fda",
+			Sha256:        "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr",
+			Fetcher:       fetcherFetchgit,
+			ModulePath:    "github.com/orivej/e",
+			Version:       "v0.0.0-20180728214217-ac3492690fda",
+		},
+		{
+			GoPackagePath: "golang.org/x/sys",
+			URL:           "https://go.googlesource.com/sys",
+			Rev:           "d99a578cf41b",
+			Sha256:        "10q9xx4pmnq92qn6ff4xp7n1hx766wvw2rf7pqcd6rx5plgwz8cm",
+			Fetcher:       fetcherFetchgit,
+			ModulePath:    "golang.org/x/sys",
+			Version:       "v0.0.0-20200323222414-d99a578cf41b",
+		},
+	}
+
+	dir := t.TempDir()
+	depsNix := filepath.Join(dir, "deps.nix")
+	if err := writeDepsNix(depsNix, packages, &options{format: formatNix}); err != nil {
+		t.Fatal(err)
+	}
+	gomod2nixToml := filepath.Join(dir, "gomod2nix.toml")
+	if err := writeGomod2nix(gomod2nixToml, packages); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedNix := `# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+[
+  {
+    goPackagePath = "github.com/orivej/e";
+    fetch = {
+      type = "git";
+      url = "https://github.com/orivej/e";
+      rev = "ac3492690fda";
+      sha256 = "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr";
+    };
+  }
+  {
+    goPackagePath = "golang.org/x/sys";
+    fetch = {
+      type = "git";
+      url = "https://go.googlesource.com/sys";
+      rev = "d99a578cf41b";
+      sha256 = "10q9xx4pmnq92qn6ff4xp7n1hx766wvw2rf7pqcd6rx5plgwz8cm";
+    };
+  }
+]
+`
+	expectedToml := `# file generated from go.mod using vgo2nix (https://github.com/adisbladis/vgo2nix)
+schema = 3
+
+[mod]
+  [mod."github.com/orivej/e"]
+    version = "v0.0.0-20180728214217-ac3492690fda"
+    hash = "sha256-edKIDucWJQabDkRDixUCVzIT8S3pdcn0N3m6iUT+UYY="
+  [mod."golang.org/x/sys"]
+    version = "v0.0.0-20200323222414-d99a578cf41b"
+    hash = "sha256-laHPH72lZ9MYvsdlwTc35nQY7LmdOGcsFgnbeknvCYM="
+`
+	for filePath, expected := range map[string]string{depsNix: expectedNix, gomod2nixToml: expectedToml} {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("%s is\n%s\nexpected\n%s", filepath.Base(filePath), data, expected)
+		}
+	}
+
+	// Both carry the same hash of every package, base32 in deps.nix and SRI
+	// in gomod2nix.toml
+	sriHashes := map[string]string{
+		"github.com/orivej/e": "sha256-edKIDucWJQabDkRDixUCVzIT8S3pdcn0N3m6iUT+UYY=",
+		"golang.org/x/sys":    "sha256-laHPH72lZ9MYvsdlwTc35nQY7LmdOGcsFgnbeknvCYM=",
+	}
+	read := loadDepsNix(depsNix)
+	for _, pkg := range packages {
+		readPkg := read[pkg.GoPackagePath]
+		if readPkg == nil {
+			t.Errorf("%s is missing from deps.nix", pkg.GoPackagePath)
+			continue
+		}
+		if readPkg.URL != pkg.URL || readPkg.Rev != pkg.Rev {
+			t.Errorf("%s reads back from deps.nix as %+v", pkg.GoPackagePath, readPkg)
+		}
+		if hash, err := base32Hash(sriHashes[pkg.ModulePath]); err != nil || hash != readPkg.Sha256 {
+			t.Errorf("%s has the hash %s in deps.nix and %s in gomod2nix.toml", pkg.GoPackagePath, readPkg.Sha256, sriHashes[pkg.ModulePath])
+		}
+	}
+}
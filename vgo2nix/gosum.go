@@ -0,0 +1,84 @@
+package vgo2nix
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadGoSum reads the module tree hashes from a go.sum file keyed by
+// module@version. The hashes of go.mod files (version/go.mod) are skipped.
+func loadGoSum(filePath string) (map[string]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed line", filePath, lineno)
+		}
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		sums[fields[0]+"@"+fields[1]] = fields[2]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sums, nil
+}
+
+type goSumSidecarEntry struct {
+	GoPackagePath string `json:"goPackagePath"`
+	Module        string `json:"module"`
+	Version       string `json:"version"`
+	Sha256        string `json:"sha256"`
+	H1            string `json:"h1"`
+}
+
+// writeGoSumSidecar writes the nix hash of every package next to the go.sum
+// hash of the module it was fetched for, so the two can be audited together.
+func writeGoSumSidecar(filePath string, goSumPath string, packages []*Package) error {
+	sums, err := loadGoSum(goSumPath)
+	if os.IsNotExist(err) {
+		sums = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+
+	entries := make([]*goSumSidecarEntry, 0, len(packages))
+	for _, pkg := range packages {
+		// go.sum may lack modules the go command has downloaded anyway
+		h1, ok := sums[pkg.ModulePath+"@"+pkg.Version]
+		if !ok {
+			h1, _ = modCacheZipHash(pkg.ModulePath, pkg.Version)
+		}
+		entries = append(entries, &goSumSidecarEntry{
+			GoPackagePath: pkg.GoPackagePath,
+			Module:        pkg.ModulePath,
+			Version:       pkg.Version,
+			Sha256:        pkg.Sha256,
+			H1:            h1,
+		})
+	}
+
+	out, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filePath, append(out, '\n'))
+}
@@ -0,0 +1,112 @@
+package vgo2nix
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Nix uses its own base32 alphabet (omitting e, o, t and u) and encodes the
+// least significant bits first.
+const nixBase32Alphabet = "0123456789abcdfghijklmnpqrsvwxyz"
+
+func nixBase32Encode(hash []byte) string {
+	n := (len(hash)*8-1)/5 + 1
+	out := make([]byte, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		b := uint(i * 5)
+		k := b / 8
+		j := b % 8
+		c := uint(hash[k]) >> j
+		if int(k)+1 < len(hash) {
+			c |= uint(hash[k+1]) << (8 - j)
+		}
+		out = append(out, nixBase32Alphabet[c&0x1f])
+	}
+	return string(out)
+}
+
+func nixBase32Decode(s string) ([]byte, error) {
+	hash := make([]byte, len(s)*5/8)
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(nixBase32Alphabet, s[len(s)-i-1])
+		if digit < 0 {
+			return nil, fmt.Errorf("Invalid character %q in base32 hash %s", s[len(s)-i-1], s)
+		}
+		b := uint(i * 5)
+		k := b / 8
+		j := b % 8
+		hash[k] |= byte(digit << j)
+		carry := byte(digit >> (8 - j))
+		if int(k)+1 < len(hash) {
+			hash[k+1] |= carry
+		} else if carry != 0 {
+			return nil, fmt.Errorf("Invalid base32 hash %s", s)
+		}
+	}
+	return hash, nil
+}
+
+// sriHash converts a base32 sha256 as printed by nix-prefetch-git into the SRI
+// form (sha256-<base64>) used by narHash and hash attributes.
+func sriHash(sha256 string) (string, error) {
+	hash, err := nixBase32Decode(sha256)
+	if err != nil {
+		return "", err
+	}
+	return "sha256-" + base64.StdEncoding.EncodeToString(hash), nil
+}
+
+// base32Hash is the inverse of sriHash.
+func base32Hash(sri string) (string, error) {
+	if !strings.HasPrefix(sri, "sha256-") {
+		return "", fmt.Errorf("Unsupported hash %s", sri)
+	}
+	hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sri, "sha256-"))
+	if err != nil {
+		return "", err
+	}
+	return nixBase32Encode(hash), nil
+}
+
+// printedHash returns the hash of a prefetch result as printed. Newer versions
+// of nix-prefetch-git print an SRI hash, either as sha256 or as hash.
+func printedHash(resp map[string]interface{}) string {
+	if hash, _ := resp["sha256"].(string); hash != "" {
+		return hash
+	}
+	hash, _ := resp["hash"].(string)
+	return hash
+}
+
+// base32Sha256Len is the length of a sha256 in Nix's base32
+const base32Sha256Len = 52
+
+// validBase32Sha256 reports whether hash is a sha256 in Nix's base32, the
+// right length and alphabet and without bits beyond the 256 of the hash.
+func validBase32Sha256(hash string) bool {
+	if len(hash) != base32Sha256Len {
+		return false
+	}
+	_, err := nixBase32Decode(hash)
+	return err == nil
+}
+
+// base32OfPrinted converts a hash printed by a prefetcher to base32, which is
+// how hashes are kept whatever form they are written in.
+func base32OfPrinted(hash string) (string, error) {
+	if hash == "" {
+		return "", fmt.Errorf("The prefetcher reported no hash")
+	}
+	base32 := hash
+	if strings.HasPrefix(hash, "sha256-") {
+		var err error
+		if base32, err = base32Hash(hash); err != nil {
+			return "", fmt.Errorf("The prefetcher reported an invalid hash %s: %v", hash, err)
+		}
+	}
+	if !validBase32Sha256(base32) {
+		return "", fmt.Errorf("The prefetcher reported an invalid sha256 %s", hash)
+	}
+	return base32, nil
+}
@@ -0,0 +1,64 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"sync"
+)
+
+// hostBreaker stops fetching from a host once as many fetches of it as its
+// limit failed in a row, as happens when the host is down, so that the
+// remaining modules fail right away instead of each waiting for a timeout of
+// its own. A nil hostBreaker never stops fetching.
+type hostBreaker struct {
+	mu       sync.Mutex
+	limit    int
+	failures map[string]int
+}
+
+func newHostBreaker(limit int) *hostBreaker {
+	return &hostBreaker{limit: limit, failures: make(map[string]int)}
+}
+
+// check returns an error of kind prefetchHostUnavailable if the host of
+// repoURL is not fetched from anymore
+func (b *hostBreaker) check(repoURL string) error {
+	if b == nil {
+		return nil
+	}
+	host := repoHost(repoURL)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures[host] < b.limit {
+		return nil
+	}
+	return &prefetchError{
+		kind:   prefetchHostUnavailable,
+		err:    fmt.Errorf("not fetching %s", repoURL),
+		detail: fmt.Sprintf("%d fetches from %s failed in a row", b.failures[host], host),
+	}
+}
+
+// record counts a failed fetch from the host of repoURL if it looks like the
+// host is unreachable, and resets the count on success
+func (b *hostBreaker) record(repoURL string, err *prefetchError) {
+	if b == nil {
+		return
+	}
+	host := repoHost(repoURL)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures[host] >= b.limit {
+		return
+	}
+	if err == nil {
+		b.failures[host] = 0
+		return
+	}
+	if err.kind != prefetchNetwork && err.kind != prefetchTimeout {
+		return
+	}
+	b.failures[host]++
+	if b.failures[host] == b.limit {
+		logf("Giving up on %s after %d failed fetches in a row, the remaining modules from it fail right away", host, b.limit)
+	}
+}
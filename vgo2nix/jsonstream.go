@@ -0,0 +1,68 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// maxJSONValue bounds the size of a single value of a JSON stream
+const maxJSONValue = 16 << 20
+
+// scanJSONValues is a bufio.SplitFunc returning the top-level values of a
+// stream of JSON objects as go list -json prints them. Unlike json.Decoder it
+// finds the end of an object by its braces alone, so reading can go on after
+// a malformed one. Bytes outside of objects and a truncated object at the end
+// are returned as they are, to fail decoding.
+func scanJSONValues(data []byte, atEOF bool) (int, []byte, error) {
+	start := bytes.IndexFunc(data, func(r rune) bool { return !unicode.IsSpace(r) })
+	if start < 0 {
+		return len(data), nil, nil
+	}
+
+	if data[start] != '{' {
+		end := bytes.IndexByte(data[start:], '{')
+		if end >= 0 {
+			return start + end, data[start : start+end], nil
+		} else if atEOF {
+			return len(data), data[start:], nil
+		}
+		return start, nil, nil
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1, data[start : i+1], nil
+			}
+		}
+	}
+	if atEOF {
+		return len(data), data[start:], nil
+	}
+	return start, nil, nil
+}
+
+// quoteJSONValue shows a value that failed to decode in an error message,
+// shortened if it is long
+func quoteJSONValue(value []byte) string {
+	const maxShown = 500
+	if len(value) > maxShown {
+		return string(value[:maxShown]) + "..."
+	}
+	return string(value)
+}
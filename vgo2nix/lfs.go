@@ -0,0 +1,17 @@
+package vgo2nix
+
+// fetchesLFS reports whether git-lfs content is fetched for a module, which
+// matches if either its module path or its goPackagePath matches a glob.
+func (opts *options) fetchesLFS(modulePath string, goPackagePath string) bool {
+	return matchesModule(opts.lfs, modulePath, goPackagePath)
+}
+
+// lfsCacheFetcher is the fetcher hashes are cached under. Fetching git-lfs
+// content replaces the pointer files with the actual files, so the hash
+// differs from the one without.
+func lfsCacheFetcher(fetcher string, lfs bool) string {
+	if lfs {
+		return fetcher + "+lfs"
+	}
+	return fetcher
+}
@@ -0,0 +1,197 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// autoJobs is the number of parallel fetches for --jobs 0
+func autoJobs() int {
+	return jobsForCPUs(runtime.NumCPU())
+}
+
+// jobsForCPUs returns the number of parallel fetches for a number of CPUs.
+// Fetches mostly wait on the network, so there are twice as many as CPUs,
+// and at least 2 should the count of CPUs be unknown.
+func jobsForCPUs(cpus int) int {
+	if cpus < 1 {
+		cpus = 1
+	}
+	return cpus * 2
+}
+
+// limiter bounds the number of concurrent fetches to a limit that may change
+// while fetches are running. A nil limiter does not limit anything.
+type limiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newLimiter(limit int) *limiter {
+	l := &limiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *limiter) acquire() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+func (l *limiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Broadcast()
+}
+
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.cond.Broadcast()
+}
+
+// adaptiveLimiter starts with a modest number of concurrent fetches, raises it
+// by one after a streak of as many successful fetches as are running and
+// halves it on every failure. With rateLimitsOnly it starts at the maximum and
+// only backs off when a host rate limits the fetches.
+type adaptiveLimiter struct {
+	*limiter
+	min            int
+	max            int
+	rateLimitsOnly bool
+
+	mu        sync.Mutex
+	streak    int
+	start     int
+	peak      int
+	raised    int
+	lowered   int
+	lastLimit int
+}
+
+const adaptiveStartJobs = 4
+
+// newAdaptiveLimiter limits the fetches to between min and max, starting
+// with adaptiveStartJobs of them but no more than start.
+func newAdaptiveLimiter(min int, start int, max int) *adaptiveLimiter {
+	if start > adaptiveStartJobs {
+		start = adaptiveStartJobs
+	}
+	start = clampJobs(start, min, max)
+	return &adaptiveLimiter{
+		limiter:   newLimiter(start),
+		min:       min,
+		max:       max,
+		start:     start,
+		peak:      start,
+		lastLimit: start,
+	}
+}
+
+// newRateLimitLimiter limits the fetches to between min and max, starting
+// with start of them.
+func newRateLimitLimiter(min int, start int, max int) *adaptiveLimiter {
+	start = clampJobs(start, min, max)
+	return &adaptiveLimiter{
+		limiter:        newLimiter(start),
+		min:            min,
+		max:            max,
+		rateLimitsOnly: true,
+		start:          start,
+		peak:           start,
+		lastLimit:      start,
+	}
+}
+
+func clampJobs(jobs int, min int, max int) int {
+	if jobs > max {
+		jobs = max
+	}
+	if jobs < min {
+		jobs = min
+	}
+	return jobs
+}
+
+// recordError adjusts the limit after a fetch failed
+func (a *adaptiveLimiter) recordError(err *prefetchError) {
+	if a == nil {
+		return
+	}
+	if a.rateLimitsOnly {
+		a.record(err.kind == prefetchRateLimited)
+	} else {
+		a.record(err.transient())
+	}
+}
+
+// record adjusts the limit after a fetch finished
+func (a *adaptiveLimiter) record(failed bool) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limit := a.lastLimit
+	if failed {
+		a.streak = 0
+		if limit <= a.min {
+			return
+		}
+		limit /= 2
+		if limit < a.min {
+			limit = a.min
+		}
+		a.lowered++
+		if a.rateLimitsOnly {
+			logf("Lowering concurrency to %d after being rate limited", limit)
+		} else {
+			logf("Lowering concurrency to %d after a failed fetch", limit)
+		}
+	} else {
+		a.streak++
+		if a.streak < limit || limit == a.max {
+			return
+		}
+		a.streak = 0
+		limit++
+		a.raised++
+		logf("Raising concurrency to %d", limit)
+	}
+
+	if limit > a.peak {
+		a.peak = limit
+	}
+	a.lastLimit = limit
+	a.setLimit(limit)
+}
+
+// adapted reports whether the limit ever changed
+func (a *adaptiveLimiter) adapted() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.raised > 0 || a.lowered > 0
+}
+
+func (a *adaptiveLimiter) stats() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return fmt.Sprintf("Adaptive concurrency started at %d, peaked at %d and ended at %d (raised %d times, lowered %d times)",
+		a.start, a.peak, a.lastLimit, a.raised, a.lowered)
+}
@@ -0,0 +1,31 @@
+package vgo2nix
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestJobsForCPUs(t *testing.T) {
+	tests := []struct {
+		cpus int
+		jobs int
+	}{
+		{0, 2},
+		{1, 2},
+		{2, 4},
+		{8, 16},
+		{128, 256},
+	}
+
+	for _, test := range tests {
+		if jobs := jobsForCPUs(test.cpus); jobs != test.jobs {
+			t.Errorf("jobsForCPUs(%d) = %d, expected %d", test.cpus, jobs, test.jobs)
+		}
+	}
+}
+
+func TestAutoJobs(t *testing.T) {
+	if jobs := autoJobs(); jobs != 2*runtime.NumCPU() {
+		t.Errorf("autoJobs() = %d with %d CPUs, expected %d", jobs, runtime.NumCPU(), 2*runtime.NumCPU())
+	}
+}
@@ -0,0 +1,62 @@
+package vgo2nix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logOutput receives all progress messages, modes printing results to stdout
+// point it at stderr.
+var logOutput io.Writer = os.Stdout
+
+// logFormat is either text for people or json for one event per line
+var logFormat = logFormatText
+
+// logEvent is a progress message in the json format. Messages without an
+// event of their own are of event message.
+type logEvent struct {
+	Event   string  `json:"event"`
+	Path    string  `json:"path,omitempty"`
+	Rev     string  `json:"rev,omitempty"`
+	Sha256  string  `json:"sha256,omitempty"`
+	URL     string  `json:"url,omitempty"`
+	Seconds float64 `json:"seconds,omitempty"`
+	Message string  `json:"message,omitempty"`
+}
+
+func logf(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	if logFormat == logFormatJSON {
+		writeLogEvent(&logEvent{Event: "message", Message: msg})
+		return
+	}
+	fmt.Fprintln(logOutput, msg)
+}
+
+// logEventf logs a message marking an event, which the json format writes
+// instead of the message.
+func logEventf(event *logEvent, format string, a ...interface{}) {
+	if logFormat == logFormatJSON {
+		writeLogEvent(event)
+		return
+	}
+	fmt.Fprintln(logOutput, fmt.Sprintf(format, a...))
+}
+
+func writeLogEvent(event *logEvent) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		// Only values like a NaN duration fail, the event is still worth a line
+		fmt.Fprintf(logOutput, "%+v\n", *event)
+		return
+	}
+	// A single write keeps the lines of concurrent workers apart
+	logOutput.Write(append(line, '\n'))
+}
@@ -0,0 +1,21 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteLogEventUnmarshalable(t *testing.T) {
+	prev := logOutput
+	defer func() { logOutput = prev }()
+	var out bytes.Buffer
+	logOutput = &out
+
+	// NaN has no JSON encoding, the event is written as text instead
+	writeLogEvent(&logEvent{Event: "fetch_done", Path: "github.com/example/dep", Seconds: math.NaN()})
+	if line := out.String(); !strings.Contains(line, "fetch_done") || !strings.Contains(line, "github.com/example/dep") || !strings.HasSuffix(line, "\n") {
+		t.Errorf("wrote %q, expected a line with the event", line)
+	}
+}
@@ -0,0 +1,54 @@
+package vgo2nix
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// headerMetadataLine matches the lines headerMetadata adds below the header
+var headerMetadataLine = regexp.MustCompile(`^# (vgo2nix version|go version|modules|generated at): `)
+
+// headerMetadata returns the comment lines telling how an output file with
+// count entries was produced. The timestamp is left out unless asked for, it
+// would make every run write a different file.
+func headerMetadata(ctx context.Context, opts *options, count int, timestamp bool) []string {
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+
+	lines := []string{
+		"# vgo2nix version: " + version,
+		"# go version: " + goVersion(ctx, opts),
+		fmt.Sprintf("# modules: %d", count),
+	}
+	if timestamp {
+		lines = append(lines, "# generated at: "+time.Now().UTC().Format(time.RFC3339))
+	}
+	return lines
+}
+
+// goVersion returns the version of the go toolchain modules are listed with,
+// or "unknown" if go cannot be run.
+func goVersion(ctx context.Context, opts *options) string {
+	goBinary, goEnv, err := opts.goToolchain()
+	if err != nil {
+		return "unknown"
+	}
+	cmd := exec.CommandContext(ctx, goBinary, "version")
+	cmd.Dir = opts.dir
+	cmd.Env = append(os.Environ(), goEnv...)
+	out, err := cmd.Output()
+	// go version go1.16.15 linux/amd64
+	fields := strings.Fields(string(out))
+	if err != nil || len(fields) < 3 {
+		return "unknown"
+	}
+	return fields[2]
+}
@@ -0,0 +1,50 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mirror fetches repositories whose URL starts with from from the URL with to
+// in its place instead.
+type mirror struct {
+	from string
+	to   string
+}
+
+// parseMirrors parses from=to rewrites
+func parseMirrors(rewrites []string) ([]mirror, error) {
+	var mirrors []mirror
+	for _, rewrite := range rewrites {
+		parts := strings.SplitN(rewrite, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid mirror \"%s\", expected from=to", rewrite)
+		}
+		mirrors = append(mirrors, mirror{from: parts[0], to: parts[1]})
+	}
+	return mirrors, nil
+}
+
+// mirrorURL returns the URL repoURL is fetched from, rewritten by the mirror
+// with the longest matching prefix, or repoURL itself if none matches.
+func (opts *options) mirrorURL(repoURL string) string {
+	var found *mirror
+	for i, m := range opts.mirrors {
+		if strings.HasPrefix(repoURL, m.from) && (found == nil || len(m.from) > len(found.from)) {
+			found = &opts.mirrors[i]
+		}
+	}
+	if found == nil {
+		return repoURL
+	}
+	return found.to + strings.TrimPrefix(repoURL, found.from)
+}
+
+// mirroredURL returns fetchURL if the source was fetched from a mirror rather
+// than from repoURL, for Package.FetchURL.
+func mirroredURL(fetchURL string, repoURL string) string {
+	if fetchURL == repoURL {
+		return ""
+	}
+	return fetchURL
+}
@@ -0,0 +1,141 @@
+package vgo2nix
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// moduleList is the outcome of listing the modules of a project, along with
+// the modification times of the files it depends on
+type moduleList struct {
+	GoModTime time.Time `json:"goModTime"`
+	GoSumTime time.Time `json:"goSumTime"`
+	Modules   []goMod   `json:"modules"`
+	// The main modules passed with --main-module that go listed
+	Main []string `json:"main,omitempty"`
+}
+
+// moduleListCache keeps the modules listed by go for every project and set
+// of listing options, so that they can be reused without running go
+type moduleListCache struct {
+	path string
+}
+
+// moduleListKey tells apart the projects and the options changing what go
+// lists for them
+func moduleListKey(opts *options) (string, error) {
+	dir, err := filepath.Abs(opts.dir)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		dir,
+		"go=" + opts.goBinary,
+		"toolchain=" + opts.toolchain,
+		"mod=" + opts.modMode,
+		"package=" + opts.forPackage,
+		"main=" + strings.Join(opts.mainModules, ","),
+		"GOFLAGS=" + os.Getenv("GOFLAGS"),
+	}, " "), nil
+}
+
+// modTime returns the modification time of a file, the zero time if it does
+// not exist
+func modTime(filePath string) (time.Time, error) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime().UTC(), nil
+}
+
+func (c *moduleListCache) read() (map[string]*moduleList, error) {
+	lists := make(map[string]*moduleList)
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return lists, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, fmt.Errorf("Failed reading %s: %v", c.path, err)
+	}
+	return lists, nil
+}
+
+// load returns the modules listed for the project of opts, and marks the main
+// modules go listed in isMain. It fails if there are none or if go.mod or
+// go.sum changed since.
+func (c *moduleListCache) load(opts *options, isMain map[string]bool) ([]goMod, error) {
+	key, err := moduleListKey(opts)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	list, ok := lists[key]
+	if !ok {
+		return nil, fmt.Errorf("No modules were listed for this project with the same options yet, run once without --offline")
+	}
+	for _, name := range []string{"go.mod", "go.sum"} {
+		listed := list.GoModTime
+		if name == "go.sum" {
+			listed = list.GoSumTime
+		}
+		changed, err := modTime(filepath.Join(opts.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if !changed.Equal(listed) {
+			return nil, fmt.Errorf("%s changed since the modules were listed, run once without --offline", name)
+		}
+	}
+
+	for _, path := range list.Main {
+		isMain[path] = true
+	}
+	logf("Reusing the %d modules listed before", len(list.Modules))
+	return list.Modules, nil
+}
+
+// store records the modules listed for the project of opts
+func (c *moduleListCache) store(opts *options, mods []goMod, isMain map[string]bool) error {
+	key, err := moduleListKey(opts)
+	if err != nil {
+		return err
+	}
+	list := &moduleList{Modules: mods}
+	if list.GoModTime, err = modTime(filepath.Join(opts.dir, "go.mod")); err != nil {
+		return err
+	}
+	if list.GoSumTime, err = modTime(filepath.Join(opts.dir, "go.sum")); err != nil {
+		return err
+	}
+	for path, seen := range isMain {
+		if seen {
+			list.Main = append(list.Main, path)
+		}
+	}
+	sort.Strings(list.Main)
+
+	lists, err := c.read()
+	if err != nil {
+		// A broken cache is replaced rather than kept forever
+		lists = make(map[string]*moduleList)
+	}
+	lists[key] = list
+	data, err := json.MarshalIndent(lists, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, append(data, '\n'))
+}
@@ -0,0 +1,237 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// escapeModulePath applies the case-encoding used by module proxies and the
+// module cache, which replaces every upper case letter with an exclamation
+// mark followed by the lower case letter, e.g. github.com/Azure becomes
+// github.com/!azure. This keeps paths unique on case-insensitive filesystems.
+func escapeModulePath(path string) (string, error) {
+	var b strings.Builder
+	for _, r := range path {
+		if r == '!' || r >= unicode.MaxASCII {
+			return "", fmt.Errorf("Invalid character %q in module path %s", r, path)
+		}
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), nil
+}
+
+// escapeModuleVersion applies the same encoding to versions, which may
+// contain upper case letters in pre-release and build suffixes.
+func escapeModuleVersion(version string) (string, error) {
+	return escapeModulePath(version)
+}
+
+// pseudoVersion matches the versions go gives commits, in all three forms
+// (v0.0.0-yyyymmddhhmmss-abcdef123456 without any earlier tag,
+// vX.Y.Z-pre.0.yyyymmddhhmmss-abcdef123456 after a pre-release and
+// vX.Y.(Z+1)-0.yyyymmddhhmmss-abcdef123456 after a release), and with the
+// +incompatible suffix of v2+ modules without a go.mod.
+var pseudoVersion = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+-(?:[^+]*\.)?[0-9]{14}-([0-9a-f]+)(?:\+incompatible)?$`)
+
+// incompatibleVersion matches tagged versions of v2+ modules without a go.mod
+var incompatibleVersion = regexp.MustCompile(`^(v[0-9]+\.[0-9]+\.[0-9]+(?:-[^+]*)?)\+incompatible$`)
+
+// versionRev returns the rev a module version is fetched at: the commit of a
+// pseudo-version, or else the tag, which never carries +incompatible. The
+// /vN suffix of the module path plays no part, a v2 module at the root of its
+// repository is tagged v2.1.0 like any other; subdirTag covers modules in
+// subdirectories.
+func versionRev(version string) string {
+	if m := pseudoVersion.FindStringSubmatch(version); m != nil {
+		return m[1]
+	}
+	if m := incompatibleVersion.FindStringSubmatch(version); m != nil {
+		return m[1]
+	}
+	return version
+}
+
+// gopkgIn matches gopkg.in import paths, gopkg.in/pkg.vN for
+// github.com/go-pkg/pkg and gopkg.in/user/pkg.vN for github.com/user/pkg
+var gopkgIn = regexp.MustCompile(`^gopkg\.in/(?:([a-zA-Z0-9][-a-zA-Z0-9]*)/)?([a-zA-Z][-.a-zA-Z0-9]*)\.(v[0-9]+(?:-unstable)?)(?:/|$)`)
+
+// gopkgInRepo returns the GitHub repository gopkg.in serves an import path
+// from, its import path root and the branch gopkg.in falls back to when no
+// tag of the major version exists, or "" for other import paths.
+func gopkgInRepo(importPath string) (repo string, root string, branch string) {
+	m := gopkgIn.FindStringSubmatch(importPath)
+	if m == nil {
+		return "", "", ""
+	}
+	user := m[1]
+	if user == "" {
+		user = "go-" + m[2]
+	}
+	return "https://github.com/" + user + "/" + m[2], strings.TrimSuffix(m[0], "/"), m[3]
+}
+
+// subdirTag returns the tag go looks up a version of a module in a
+// subdirectory of the repository at goPackagePath under, e.g. sub/v1.2.3, or
+// "" for modules at the root of it. The /vN suffix of a major version is no
+// part of the prefix. +incompatible versions have no go.mod and so cannot be
+// modules of a subdirectory, their plain tag is the one of the root.
+func subdirTag(goPackagePath string, modulePath string, version string) string {
+	rev := versionRev(version)
+	if !semverTag.MatchString(rev) || rev != version {
+		return ""
+	}
+	dir := moduleSubdir(goPackagePath, modulePath)
+	if dir == "" {
+		return ""
+	}
+	return dir + "/" + rev
+}
+
+// replacedPackagePath returns the goPackagePath of a module fetched from the
+// repository at replaceRoot of the module replacing it, which has to go where
+// the replaced module is imported from. A replacement at the root of its
+// repository goes to the import path of the module, one in a directory to
+// where that directory ends up at the import path.
+func replacedPackagePath(importPath string, replacePath string, replaceRoot string) (string, error) {
+	if moduleSubdir(replaceRoot, replacePath) == "" {
+		return importPath, nil
+	}
+	dir := strings.TrimPrefix(replacePath, replaceRoot)
+	if !strings.HasSuffix(importPath, dir) {
+		return "", fmt.Errorf("its replacement %s is in the directory %s of its repository, which %s does not end in", replacePath, strings.TrimPrefix(dir, "/"), importPath)
+	}
+	return strings.TrimSuffix(importPath, dir), nil
+}
+
+// moduleSubdir returns the directory of a module in the repository at
+// goPackagePath without the /vN suffix of a major version, which may be a
+// directory of its own or not, or "" for modules at the root of it.
+func moduleSubdir(goPackagePath string, modulePath string) string {
+	if !strings.HasPrefix(modulePath, goPackagePath+"/") {
+		return ""
+	}
+	dir := strings.TrimPrefix(modulePath, goPackagePath+"/")
+	if m := majorVersionDir.FindStringSubmatch(dir); m != nil {
+		dir = m[1]
+	}
+	return dir
+}
+
+func goModCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// modCacheZipHash returns the h1: hash the go command recorded for a module
+// in its download cache.
+func modCacheZipHash(modulePath string, version string) (string, error) {
+	escapedPath, err := escapeModulePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := escapeModuleVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(goModCacheDir(), "cache", "download",
+		filepath.FromSlash(escapedPath), "@v", escapedVersion+".ziphash"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// compareVersions orders two module versions by semantic versioning, in which
+// pseudo-versions are prereleases. It returns -1, 0 or 1 like strings.Compare.
+func compareVersions(a string, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+	for i := range aCore {
+		if c := compareNumeric(aCore[i], bCore[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A release is higher than its prereleases
+	switch {
+	case aPre == "" && bPre == "":
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	aIDs := strings.Split(aPre, ".")
+	bIDs := strings.Split(bPre, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNumeric := strings.Trim(aIDs[i], "0123456789") == ""
+		bNumeric := strings.Trim(bIDs[i], "0123456789") == ""
+		var c int
+		switch {
+		case aNumeric && bNumeric:
+			c = compareNumeric(aIDs[i], bIDs[i])
+		case aNumeric:
+			c = -1
+		case bNumeric:
+			c = 1
+		default:
+			c = strings.Compare(aIDs[i], bIDs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	// A prerelease with more identifiers is higher
+	switch {
+	case len(aIDs) < len(bIDs):
+		return -1
+	case len(aIDs) > len(bIDs):
+		return 1
+	}
+	return 0
+}
+
+// splitVersion splits a version into major, minor and patch and its
+// prerelease, dropping build metadata such as +incompatible
+func splitVersion(version string) ([3]string, string) {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	var pre string
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version, pre = version[:i], version[i+1:]
+	}
+	core := [3]string{"0", "0", "0"}
+	copy(core[:], strings.SplitN(version, ".", 3))
+	return core, pre
+}
+
+// compareNumeric compares two decimal numbers of any length
+func compareNumeric(a string, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
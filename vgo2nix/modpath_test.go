@@ -0,0 +1,92 @@
+package vgo2nix
+
+import "testing"
+
+func TestVersionRev(t *testing.T) {
+	tests := []struct {
+		version string
+		rev     string
+	}{
+		// Pseudo-versions without an earlier tag, after a pre-release and
+		// after a release
+		{"v0.0.0-20190308221718-c2843e01d9a2", "c2843e01d9a2"},
+		{"v1.2.4-beta.1.0.20200101000000-0123456789ab", "0123456789ab"},
+		{"v1.2.4-0.20200101000000-0123456789ab", "0123456789ab"},
+		{"v2.0.1-0.20200101000000-abcdef012345+incompatible", "abcdef012345"},
+		// +incompatible tags
+		{"v2.1.0+incompatible", "v2.1.0"},
+		{"v3.0.0-rc.1+incompatible", "v3.0.0-rc.1"},
+		// Plain tags
+		{"v1.2.3", "v1.2.3"},
+		{"v2.1.0", "v2.1.0"},
+		{"v1.0.0-rc.1", "v1.0.0-rc.1"},
+		// A pre-release that only looks like a pseudo-version
+		{"v1.0.0-20200101000000", "v1.0.0-20200101000000"},
+	}
+
+	for _, test := range tests {
+		if rev := versionRev(test.version); rev != test.rev {
+			t.Errorf("versionRev(%q) = %q, expected %q", test.version, rev, test.rev)
+		}
+	}
+}
+
+func TestSubdirTag(t *testing.T) {
+	tests := []struct {
+		goPackagePath string
+		modulePath    string
+		version       string
+		tag           string
+	}{
+		// Modules at the root of the repository, with and without /vN
+		{"github.com/example/repo", "github.com/example/repo", "v1.2.3", ""},
+		{"github.com/example/repo", "github.com/example/repo/v2", "v2.1.0", ""},
+		// Modules in subdirectories, nested and with /vN
+		{"github.com/example/repo", "github.com/example/repo/sub", "v1.2.3", "sub/v1.2.3"},
+		{"github.com/example/repo", "github.com/example/repo/a/b", "v0.4.0", "a/b/v0.4.0"},
+		{"github.com/example/repo", "github.com/example/repo/sub/v2", "v2.1.0", "sub/v2.1.0"},
+		{"github.com/example/repo", "github.com/example/repo/a/b/v3", "v3.0.0-rc.1", "a/b/v3.0.0-rc.1"},
+		// Versions without a tag of the subdirectory
+		{"github.com/example/repo", "github.com/example/repo/sub", "v0.0.0-20200101000000-0123456789ab", ""},
+		{"github.com/example/repo", "github.com/example/repo/sub", "v2.1.0+incompatible", ""},
+		// A module path merely sharing a prefix with the repository
+		{"github.com/example/repo", "github.com/example/repository", "v1.2.3", ""},
+	}
+
+	for _, test := range tests {
+		if tag := subdirTag(test.goPackagePath, test.modulePath, test.version); tag != test.tag {
+			t.Errorf("subdirTag(%q, %q, %q) = %q, expected %q", test.goPackagePath, test.modulePath, test.version, tag, test.tag)
+		}
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		escaped string
+	}{
+		{"github.com/example/dep", "github.com/example/dep"},
+		{"github.com/Azure/azure-sdk-for-go", "github.com/!azure/azure-sdk-for-go"},
+		{"github.com/BurntSushi/toml", "github.com/!burnt!sushi/toml"},
+		{"github.com/Sirupsen/logrus", "github.com/!sirupsen/logrus"},
+		// Azure DevOps repositories, which go imports with a .git suffix
+		{"dev.azure.com/org/proj/_git/repo", "dev.azure.com/org/proj/_git/repo"},
+		{"dev.azure.com/org/proj/_git/repo.git", "dev.azure.com/org/proj/_git/repo.git"},
+		{"dev.azure.com/MyOrg/MyProject/_git/MyRepo.git", "dev.azure.com/!my!org/!my!project/_git/!my!repo.git"},
+	}
+
+	for _, test := range tests {
+		escaped, err := escapeModulePath(test.path)
+		if err != nil {
+			t.Errorf("escapeModulePath(%q) failed: %v", test.path, err)
+		} else if escaped != test.escaped {
+			t.Errorf("escapeModulePath(%q) = %q, expected %q", test.path, escaped, test.escaped)
+		}
+	}
+
+	for _, path := range []string{"github.com/example/!dep", "github.com/exämple/dep"} {
+		if escaped, err := escapeModulePath(path); err == nil {
+			t.Errorf("escapeModulePath(%q) = %q, expected an error", path, escaped)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// moduleDirs returns the directories of all modules to list, opts.dir first
+func (opts *options) moduleDirs() []string {
+	dirs := []string{opts.dir}
+	for _, dir := range opts.moreDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(opts.dir, dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// dirName names opts.dir in messages
+func (opts *options) dirName() string {
+	if opts.dir == "" {
+		return "."
+	}
+	return opts.dir
+}
+
+// mergeModules adds the entries listed in dir to merged, which records the
+// directory of every entry in listedIn. A module listed at another version
+// before fails the merge, unless highest picks the highest of the versions.
+func mergeModules(merged map[string]*modEntry, listedIn map[string]string, entries []*modEntry, dir string, highest bool) error {
+	for _, entry := range entries {
+		prev, ok := merged[entry.importPath]
+		if !ok {
+			merged[entry.importPath] = entry
+			listedIn[entry.importPath] = dir
+			continue
+		}
+		if prev.replacePath != entry.replacePath {
+			return fmt.Errorf("%s is replaced by %s in %s but by %s in %s", entry.importPath, prev.downloadPath(), listedIn[entry.importPath], entry.downloadPath(), dir)
+		}
+		if prev.version == entry.version {
+			continue
+		}
+		if !highest {
+			return fmt.Errorf("%s is at %s in %s but at %s in %s, --resolve=highest uses the highest version", entry.importPath, prev.version, listedIn[entry.importPath], entry.version, dir)
+		}
+		if compareVersions(entry.version, prev.version) > 0 {
+			logf("Using %s %s of %s over %s of %s", entry.importPath, entry.version, dir, prev.version, listedIn[entry.importPath])
+			merged[entry.importPath] = entry
+			listedIn[entry.importPath] = dir
+		} else {
+			logf("Using %s %s of %s over %s of %s", entry.importPath, prev.version, listedIn[entry.importPath], entry.version, dir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package vgo2nix
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// narWriter serializes files into the Nix archive format, the hash of which
+// is the hash Nix gives to a path.
+type narWriter struct {
+	w io.Writer
+}
+
+func (n *narWriter) str(s string) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(s)))
+	if _, err := n.w.Write(buf[:]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(n.w, s); err != nil {
+		return err
+	}
+	padding := (8 - len(s)%8) % 8
+	_, err := n.w.Write(make([]byte, padding))
+	return err
+}
+
+func (n *narWriter) strs(strs ...string) error {
+	for _, s := range strs {
+		if err := n.str(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *narWriter) file(filePath string, info os.FileInfo) error {
+	if err := n.strs("(", "type", "regular"); err != nil {
+		return err
+	}
+	if info.Mode()&0100 != 0 {
+		if err := n.strs("executable", ""); err != nil {
+			return err
+		}
+	}
+	// The length has to come first, so the contents are not streamed
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	if err := n.strs("contents", string(contents)); err != nil {
+		return err
+	}
+	return n.str(")")
+}
+
+func (n *narWriter) path(filePath string) error {
+	info, err := os.Lstat(filePath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode().IsRegular():
+		return n.file(filePath, info)
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(filePath)
+		if err != nil {
+			return err
+		}
+		return n.strs("(", "type", "symlink", "target", target, ")")
+	case !info.IsDir():
+		return fmt.Errorf("Cannot archive %s of mode %s", filePath, info.Mode())
+	}
+
+	if err := n.strs("(", "type", "directory"); err != nil {
+		return err
+	}
+	// os.ReadDir sorts by name bytewise, as Nix does
+	children, err := os.ReadDir(filePath)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := n.strs("entry", "(", "name", child.Name(), "node"); err != nil {
+			return err
+		}
+		if err := n.path(filepath.Join(filePath, child.Name())); err != nil {
+			return err
+		}
+		if err := n.str(")"); err != nil {
+			return err
+		}
+	}
+	return n.str(")")
+}
+
+// narHash returns the sha256 of the Nix archive of a path
+func narHash(filePath string) ([]byte, error) {
+	h := sha256.New()
+	n := &narWriter{w: h}
+	if err := n.str("nix-archive-1"); err != nil {
+		return nil, err
+	}
+	if err := n.path(filePath); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,39 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// packageModules returns the paths of the modules providing importPath and
+// all packages it imports, directly or indirectly.
+func packageModules(ctx context.Context, dir string, goBinary string, goEnv []string, modMode string, importPath string) (map[string]bool, error) {
+	args := []string{"list", "-deps", "-f", "{{with .Module}}{{.Path}}{{end}}"}
+	if modMode != "" {
+		args = append(args, "-mod="+modMode)
+	}
+	args = append(args, importPath)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, goBinary, args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"GO111MODULE=on",
+	)
+	cmd.Env = append(cmd.Env, goEnv...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("'go list -deps %s' failed with %s:\n%s", importPath, err, stderr.String())
+	}
+
+	modules := make(map[string]bool)
+	for _, path := range strings.Fields(string(out)) {
+		modules[path] = true
+	}
+	return modules, nil
+}
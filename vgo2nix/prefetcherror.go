@@ -0,0 +1,155 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// prefetchErrorKind tells failures of nix-prefetch-git apart by what went
+// wrong, which decides whether retrying or falling back can help.
+type prefetchErrorKind int
+
+const (
+	prefetchUnknown prefetchErrorKind = iota
+	prefetchRevNotFound
+	prefetchNetwork
+	prefetchAuth
+	prefetchDiskFull
+	prefetchEmptyTree
+	prefetchTimeout
+	prefetchRateLimited
+	prefetchHostUnavailable
+)
+
+func (kind prefetchErrorKind) String() string {
+	switch kind {
+	case prefetchRevNotFound:
+		return "rev not found"
+	case prefetchNetwork:
+		return "network error"
+	case prefetchAuth:
+		return "authentication failed"
+	case prefetchDiskFull:
+		return "disk full"
+	case prefetchEmptyTree:
+		return "empty tree"
+	case prefetchTimeout:
+		return "timed out"
+	case prefetchRateLimited:
+		return "rate limited"
+	case prefetchHostUnavailable:
+		return "host unavailable"
+	}
+	return "fetch failed"
+}
+
+// prefetchErrorPatterns are matched against the stderr of the prefetcher in
+// order, authentication failures are reported by git much like network ones
+// and hosts may answer with 403 when rate limiting. The "Unable to checkout"
+// nix-prefetch-git ends every failed clone with tells nothing apart.
+var prefetchErrorPatterns = []struct {
+	kind     prefetchErrorKind
+	patterns []string
+}{
+	{prefetchRateLimited, []string{
+		"The requested URL returned error: 429",
+		"429 Too Many Requests",
+		"rate limit exceeded",
+		"secondary rate limit",
+	}},
+	{prefetchDiskFull, []string{
+		"No space left on device",
+		"Disk quota exceeded",
+	}},
+	{prefetchAuth, []string{
+		"Permission denied",
+		"Authentication failed",
+		"could not read Username",
+		"could not read Password",
+		"terminal prompts disabled",
+		"Repository not found",
+		"The requested URL returned error: 401",
+		"The requested URL returned error: 403",
+		"Host key verification failed",
+	}},
+	{prefetchRevNotFound, []string{
+		"couldn't find remote ref",
+		"did not match any file(s) known to git",
+		"unknown revision",
+		"ambiguous argument",
+		"reference is not a tree",
+		"not our ref",
+		"bad object",
+		"404 Not Found",
+		"The requested URL returned error: 404",
+	}},
+	{prefetchNetwork, []string{
+		"Could not resolve host",
+		"Connection timed out",
+		"Connection refused",
+		"Connection reset",
+		"Failed to connect",
+		"Network is unreachable",
+		"Operation timed out",
+		"early EOF",
+		"RPC failed",
+		"The remote end hung up unexpectedly",
+		"The requested URL returned error: 5",
+		"unable to access",
+	}},
+}
+
+// prefetchError is a failed prefetch along with its classification
+type prefetchError struct {
+	kind   prefetchErrorKind
+	err    error
+	detail string
+}
+
+func (e *prefetchError) Error() string {
+	if e.detail == "" {
+		return fmt.Sprintf("%s: %v", e.kind, e.err)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.kind, e.err, e.detail)
+}
+
+func (e *prefetchError) Unwrap() error {
+	return e.err
+}
+
+// transient reports whether the same fetch may succeed when tried again
+func (e *prefetchError) transient() bool {
+	return e.kind == prefetchNetwork || e.kind == prefetchTimeout || e.kind == prefetchRateLimited || e.kind == prefetchUnknown
+}
+
+// classifyPrefetchError classifies the error of running a prefetcher by the
+// stderr it captured, or errors of a Prefetcher by their message. The last
+// line of stderr before the "Unable to checkout" of nix-prefetch-git is kept
+// as detail, it usually holds the message of git.
+func classifyPrefetchError(err error) *prefetchError {
+	classified := &prefetchError{kind: prefetchUnknown, err: err}
+	stderr := err.Error()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		stderr = strings.TrimSpace(string(exitErr.Stderr))
+		if stderr == "" {
+			return classified
+		}
+		lines := strings.Split(stderr, "\n")
+		last := len(lines) - 1
+		if last > 0 && strings.HasPrefix(lines[last], "Unable to checkout") {
+			last--
+		}
+		classified.detail = strings.TrimSpace(lines[last])
+	}
+
+	for _, class := range prefetchErrorPatterns {
+		for _, pattern := range class.patterns {
+			if strings.Contains(stderr, pattern) {
+				classified.kind = class.kind
+				return classified
+			}
+		}
+	}
+	return classified
+}
@@ -0,0 +1,115 @@
+package vgo2nix
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyPrefetchError(t *testing.T) {
+	// The stderr of nix-prefetch-git, which ends every failed clone with the
+	// same line
+	tests := []struct {
+		name   string
+		stderr string
+		kind   prefetchErrorKind
+		detail string
+	}{
+		{
+			name: "https auth",
+			stderr: `Initialized empty Git repository in /tmp/git-checkout-tmp-Xq3fLk2r/private-v1.0.0/.git/
+fatal: could not read Username for 'https://github.com': terminal prompts disabled
+Unable to checkout refs/tags/v1.0.0 from https://github.com/example/private.
+`,
+			kind:   prefetchAuth,
+			detail: "fatal: could not read Username for 'https://github.com': terminal prompts disabled",
+		},
+		{
+			name: "ssh auth",
+			stderr: `Initialized empty Git repository in /tmp/git-checkout-tmp-7bWkN0aQ/private-v1.0.0/.git/
+git@github.com: Permission denied (publickey).
+fatal: Could not read from remote repository.
+
+Please make sure you have the correct access rights
+and the repository exists.
+Unable to checkout refs/tags/v1.0.0 from git@github.com:example/private.git.
+`,
+			kind:   prefetchAuth,
+			detail: "and the repository exists.",
+		},
+		{
+			name: "tag not found",
+			stderr: `Initialized empty Git repository in /tmp/git-checkout-tmp-Pz8cVd1e/dep-v9.9.9/.git/
+fatal: couldn't find remote ref refs/tags/v9.9.9
+Unable to checkout refs/tags/v9.9.9 from https://github.com/example/dep.
+`,
+			kind:   prefetchRevNotFound,
+			detail: "fatal: couldn't find remote ref refs/tags/v9.9.9",
+		},
+		{
+			name: "commit not found",
+			stderr: `Initialized empty Git repository in /tmp/git-checkout-tmp-Lm4sTy6u/dep-0123456/.git/
+fatal: reference is not a tree: 0123456789abcdef0123456789abcdef01234567
+Unable to checkout 0123456789abcdef0123456789abcdef01234567 from https://github.com/example/dep.
+`,
+			kind:   prefetchRevNotFound,
+			detail: "fatal: reference is not a tree: 0123456789abcdef0123456789abcdef01234567",
+		},
+		{
+			name: "unresolved host",
+			stderr: `Initialized empty Git repository in /tmp/git-checkout-tmp-Hj2kRe9w/dep-v1.0.0/.git/
+fatal: unable to access 'https://github.com/example/dep/': Could not resolve host: github.com
+Unable to checkout refs/tags/v1.0.0 from https://github.com/example/dep.
+`,
+			kind:   prefetchNetwork,
+			detail: "fatal: unable to access 'https://github.com/example/dep/': Could not resolve host: github.com",
+		},
+		{
+			name: "connection reset",
+			stderr: `Initialized empty Git repository in /tmp/git-checkout-tmp-Ws5nBc3x/dep-v1.0.0/.git/
+error: RPC failed; curl 56 OpenSSL SSL_read: Connection reset by peer, errno 104
+fatal: early EOF
+fatal: fetch-pack: invalid index-pack output
+Unable to checkout refs/tags/v1.0.0 from https://github.com/example/dep.
+`,
+			kind:   prefetchNetwork,
+			detail: "fatal: fetch-pack: invalid index-pack output",
+		},
+		{
+			name: "unknown",
+			stderr: `/nix/store/0k5pvgsg3bl2qpz2q1bh4ny8n3z7zd3p-nix-prefetch-git/bin/nix-prefetch-git: line 107: git: command not found
+Unable to checkout refs/tags/v1.0.0 from https://github.com/example/dep.
+`,
+			kind:   prefetchUnknown,
+			detail: "/nix/store/0k5pvgsg3bl2qpz2q1bh4ny8n3z7zd3p-nix-prefetch-git/bin/nix-prefetch-git: line 107: git: command not found",
+		},
+		{
+			name:   "no stderr",
+			stderr: "",
+			kind:   prefetchUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			classified := classifyPrefetchError(&exec.ExitError{Stderr: []byte(test.stderr)})
+			if classified.kind != test.kind {
+				t.Errorf("kind is %s, expected %s", classified.kind, test.kind)
+			}
+			if classified.detail != test.detail {
+				t.Errorf("detail is %q, expected %q", classified.detail, test.detail)
+			}
+		})
+	}
+}
+
+func TestClassifyPrefetcherError(t *testing.T) {
+	// Errors of a Prefetcher are classified by their message
+	classified := classifyPrefetchError(errors.New("fatal: couldn't find remote ref refs/tags/v9.9.9"))
+	if classified.kind != prefetchRevNotFound {
+		t.Errorf("kind is %s, expected %s", classified.kind, prefetchRevNotFound)
+	}
+	if classified.detail != "" {
+		t.Errorf("detail is %q, expected none", classified.detail)
+	}
+}
@@ -0,0 +1,71 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// prefetchTool returns the command to run for the prefetcher tool, which is
+// --prefetch-cmd for nix-prefetch-git if given.
+func (opts *options) prefetchTool(tool string) string {
+	if tool == "nix-prefetch-git" && opts.prefetchCmd != "" {
+		return opts.prefetchCmd
+	}
+	return tool
+}
+
+// runPrefetch runs a prefetcher in a process group of its own and returns its
+// stdout. When ctx is done or the timeout passes the whole group is killed:
+// killing just the prefetcher would leave git holding the output pipes open
+// and the wait for them hanging. A zero timeout waits forever. Systems
+// without process groups only kill the prefetcher itself.
+func runPrefetch(ctx context.Context, timeout time.Duration, env []string, name string, args ...string) ([]byte, error) {
+	fetchCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, missingToolError(name)
+		}
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-fetchCtx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+
+	if fetchCtx.Err() != nil && ctx.Err() == nil {
+		return nil, &prefetchError{
+			kind: prefetchTimeout,
+			err:  fmt.Errorf("%s did not finish within %s", name, timeout),
+		}
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
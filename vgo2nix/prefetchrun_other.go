@@ -0,0 +1,13 @@
+//go:build !unix
+
+package vgo2nix
+
+import "os/exec"
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the process of cmd alone, its children are left to
+// exit on their own
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
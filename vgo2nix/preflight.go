@@ -0,0 +1,47 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// prefetchToolHints tells how to install every prefetcher
+var prefetchToolHints = map[string]string{
+	"nix-prefetch-git": "install the nix-prefetch-scripts package, e.g. nix-env -iA nixpkgs.nix-prefetch-scripts",
+	"nix-prefetch-hg":  "install the nix-prefetch-scripts package, e.g. nix-env -iA nixpkgs.nix-prefetch-scripts",
+	"nix-prefetch-bzr": "install the nix-prefetch-scripts package, e.g. nix-env -iA nixpkgs.nix-prefetch-scripts",
+	"nix-prefetch-url": "it comes with Nix, install Nix or add it to PATH",
+}
+
+// prefetchTools returns the prefetchers fetches for fetcher run. Repositories
+// in hg or bzr need their own, which are only known once they are resolved.
+func prefetchTools(fetcher string) []string {
+	switch fetcher {
+	case fetcherProxy:
+		return []string{"nix-prefetch-url"}
+	case fetcherGitHub:
+		// Repositories not on GitHub are fetched with fetchgit
+		return []string{"nix-prefetch-url", "nix-prefetch-git"}
+	}
+	return []string{"nix-prefetch-git"}
+}
+
+// missingToolError explains how to install a prefetcher that is not on PATH
+func missingToolError(tool string) error {
+	hint, ok := prefetchToolHints[tool]
+	if !ok {
+		return fmt.Errorf("%s is not on PATH", tool)
+	}
+	return fmt.Errorf("%s is not on PATH, %s", tool, hint)
+}
+
+// checkPrefetchTools fails if any of tools is not on PATH, before the same
+// error would fail every single fetch.
+func checkPrefetchTools(tools []string) error {
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			return missingToolError(tool)
+		}
+	}
+	return nil
+}
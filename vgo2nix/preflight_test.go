@@ -0,0 +1,47 @@
+package vgo2nix
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckPrefetchTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake prefetcher is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "nix-prefetch-git"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", bin)
+	t.Cleanup(func() { os.Setenv("PATH", path) })
+
+	tests := []struct {
+		fetcher string
+		err     string
+	}{
+		{fetcherFetchgit, ""},
+		{fetcherGitHub, "nix-prefetch-url is not on PATH, it comes with Nix, install Nix or add it to PATH"},
+		{fetcherProxy, "nix-prefetch-url is not on PATH, it comes with Nix, install Nix or add it to PATH"},
+	}
+
+	for _, test := range tests {
+		err := checkPrefetchTools(prefetchTools(test.fetcher))
+		if test.err == "" && err != nil {
+			t.Errorf("Checking the prefetchers of %s failed: %v", test.fetcher, err)
+		} else if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("Checking the prefetchers of %s gave %v, expected %s", test.fetcher, err, test.err)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(bin, "nix-prefetch-git")); err != nil {
+		t.Fatal(err)
+	}
+	expected := "nix-prefetch-git is not on PATH, install the nix-prefetch-scripts package, e.g. nix-env -iA nixpkgs.nix-prefetch-scripts"
+	if err := checkPrefetchTools(prefetchTools(fetcherFetchgit)); err == nil || err.Error() != expected {
+		t.Errorf("Checking the prefetchers without nix-prefetch-git gave %v, expected %s", err, expected)
+	}
+}
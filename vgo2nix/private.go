@@ -0,0 +1,73 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// netrcCredentialHelper answers git's credential requests from the file in
+// NETRC. libcurl only ever reads ~/.netrc by itself.
+const netrcCredentialHelper = `credential.helper=!f() { test "$1" = get || return 0; host=$(sed -n 's/^host=//p'); awk -v host="$host" '{ for (i = 1; i <= NF; i++) t[n++] = $i } END { for (i = 0; i < n; i++) { if (t[i] == "machine" || t[i] == "default") { if (m) break; m = t[i] == "default" || t[i+1] == host } else if (m && t[i] == "login") print "username=" t[i+1]; else if (m && t[i] == "password") print "password=" t[i+1] } }' "$NETRC"; }; f`
+
+// netrcEnv points the prefetchers at a netrc file: git through NETRC, which
+// netrcCredentialHelper reads, and nix-prefetch-url through the netrc-file
+// setting of Nix.
+func netrcEnv(env []string, netrc string) []string {
+	nixConfig := "netrc-file = " + netrc
+	if existing := os.Getenv("NIX_CONFIG"); existing != "" {
+		nixConfig = existing + "\n" + nixConfig
+	}
+	return append(env, "NETRC="+netrc, "NIX_CONFIG="+nixConfig)
+}
+
+// matchPrefixPatterns reports whether any of the comma separated glob
+// patterns matches a prefix of the path, like the go command matches
+// GOPRIVATE and GONOSUMDB.
+func matchPrefixPatterns(globs string, target string) bool {
+	for _, glob := range strings.Split(globs, ",") {
+		glob = strings.TrimSuffix(strings.TrimSpace(glob), "/")
+		if glob == "" {
+			continue
+		}
+		// A pattern matches as many leading elements as it has
+		n := strings.Count(glob, "/")
+		prefix := target
+		for i := 0; i < len(target); i++ {
+			if target[i] == '/' {
+				if n == 0 {
+					prefix = target[:i]
+					break
+				}
+				n--
+			}
+		}
+		if n > 0 {
+			continue
+		}
+		if matched, _ := path.Match(glob, prefix); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// sshRepoURL turns the https URL of a git repository into the scp-like form
+// git uses for SSH, so keys rather than passwords authenticate the fetch. It
+// returns "" for URLs it cannot rewrite.
+func sshRepoURL(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" || u.RawQuery != "" {
+		return ""
+	}
+	repoPath := strings.Trim(u.Path, "/")
+	if repoPath == "" {
+		return ""
+	}
+	if !strings.HasSuffix(repoPath, ".git") {
+		repoPath += ".git"
+	}
+	return fmt.Sprintf("git@%s:%s", u.Hostname(), repoPath)
+}
@@ -0,0 +1,58 @@
+package vgo2nix
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// progressInterval is how often progressLine logs a line when not writing
+// to a terminal
+const progressInterval = 5 * time.Second
+
+// progressLine shows the number of resolved modules and errors on a single
+// line for --quiet, redrawn in place on a terminal and logged every
+// progressInterval otherwise.
+type progressLine struct {
+	w        io.Writer
+	terminal bool
+	errors   int
+	last     time.Time
+	// Whether the terminal line needs a newline before other output
+	open bool
+}
+
+func newProgressLine(f *os.File) *progressLine {
+	info, err := f.Stat()
+	return &progressLine{
+		w:        f,
+		terminal: err == nil && info.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+func (p *progressLine) update(result *PackageResult, done int, total int) {
+	var wouldFetch *wouldFetchError
+	if result.Error != nil && !errors.As(result.Error, &wouldFetch) {
+		p.errors++
+	}
+	msg := fmt.Sprintf("fetching %d/%d, %d errors", done, total, p.errors)
+	if p.terminal {
+		fmt.Fprintf(p.w, "\r%s", msg)
+		p.open = true
+		return
+	}
+	if done == total || time.Since(p.last) >= progressInterval {
+		fmt.Fprintln(p.w, msg)
+		p.last = time.Now()
+	}
+}
+
+// finish ends the line on a terminal
+func (p *progressLine) finish() {
+	if p.open {
+		fmt.Fprintln(p.w)
+		p.open = false
+	}
+}
@@ -0,0 +1,55 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fetcherProxy emits fetchzip entries for the module zips of a module proxy
+// instead of fetching the repositories, which need not be reachable at all.
+const fetcherProxy = "proxy"
+
+const defaultGoProxy = "https://proxy.golang.org"
+
+// goProxyURL picks the first proxy from a GOPROXY list, skipping direct which
+// the go command resolves through the version control system.
+func goProxyURL(goproxy string) (string, error) {
+	if goproxy == "" {
+		return defaultGoProxy, nil
+	}
+	for _, proxy := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		switch proxy = strings.TrimSpace(proxy); proxy {
+		case "direct", "":
+			continue
+		case "off":
+			return "", fmt.Errorf("GOPROXY is off")
+		}
+		return strings.TrimSuffix(proxy, "/"), nil
+	}
+	return "", fmt.Errorf("GOPROXY %q names no proxy to fetch from", goproxy)
+}
+
+// proxyZipURL is the URL of the zip of a module version on the proxy, with
+// module path and version case-encoded.
+func proxyZipURL(proxy string, modulePath string, version string) (string, error) {
+	escapedPath, err := escapeModulePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := escapeModuleVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/@v/%s.zip", proxy, escapedPath, escapedVersion), nil
+}
+
+// proxySubdir is the directory of the module in its unpacked zip. All files
+// of a module zip are below module@version/, and fetchzip strips only the
+// first component of that.
+func proxySubdir(modulePath string, version string) string {
+	parts := strings.SplitN(modulePath+"@"+version, "/", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
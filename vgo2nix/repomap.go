@@ -0,0 +1,64 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// repoMapping maps import paths starting with prefix to a git repository
+// without asking the import path's server. A prefix ending in a slash maps
+// every path element below it to a repository of its own below url, e.g.
+// k8s.io/ => https://github.com/kubernetes/ maps k8s.io/api to
+// https://github.com/kubernetes/api. Any other prefix is the root of the
+// single repository at url.
+type repoMapping struct {
+	prefix string
+	url    string
+}
+
+// parseRepoMappings parses prefix=url pairs
+func parseRepoMappings(pairs []string) ([]repoMapping, error) {
+	var mappings []repoMapping
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid repo mapping \"%s\", expected prefix=url", pair)
+		}
+		if strings.HasSuffix(parts[0], "/") != strings.HasSuffix(parts[1], "/") {
+			return nil, fmt.Errorf("Invalid repo mapping \"%s\", either both or none of prefix and url end in a slash", pair)
+		}
+		mappings = append(mappings, repoMapping{prefix: parts[0], url: parts[1]})
+	}
+	return mappings, nil
+}
+
+// mappedRepoRoot returns the repository the longest matching mapping maps
+// importPath to, or nil if none matches.
+func mappedRepoRoot(mappings []repoMapping, importPath string) *vcs.RepoRoot {
+	var found *repoMapping
+	for i, mapping := range mappings {
+		matches := strings.HasPrefix(importPath, mapping.prefix)
+		if !strings.HasSuffix(mapping.prefix, "/") {
+			matches = importPath == mapping.prefix || strings.HasPrefix(importPath, mapping.prefix+"/")
+		}
+		if matches && (found == nil || len(mapping.prefix) > len(found.prefix)) {
+			found = &mappings[i]
+		}
+	}
+	if found == nil {
+		return nil
+	}
+
+	root, repo := found.prefix, found.url
+	if strings.HasSuffix(found.prefix, "/") {
+		elem := strings.SplitN(strings.TrimPrefix(importPath, found.prefix), "/", 2)[0]
+		if elem == "" {
+			return nil
+		}
+		root += elem
+		repo += elem
+	}
+	return &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: repo, Root: root}
+}
@@ -0,0 +1,124 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type reportPackage struct {
+	GoPackagePath string `json:"goPackagePath"`
+	Rev           string `json:"rev"`
+	Sha256        string `json:"sha256"`
+}
+
+type reportUpdate struct {
+	GoPackagePath string `json:"goPackagePath"`
+	OldRev        string `json:"oldRev"`
+	NewRev        string `json:"newRev"`
+	OldSha256     string `json:"oldSha256"`
+	NewSha256     string `json:"newSha256"`
+}
+
+type reportFailure struct {
+	ImportPath string `json:"importPath"`
+	Error      string `json:"error"`
+}
+
+type report struct {
+	Added   []*reportPackage `json:"added"`
+	Removed []*reportPackage `json:"removed"`
+	Updated []*reportUpdate  `json:"updated"`
+	Failed  []*reportFailure `json:"failed"`
+}
+
+func newReport(diff *depsDiff, failed []*PackageResult) *report {
+	r := &report{
+		Added:   []*reportPackage{},
+		Removed: []*reportPackage{},
+		Updated: []*reportUpdate{},
+		Failed:  []*reportFailure{},
+	}
+	for _, pkg := range diff.Added {
+		r.Added = append(r.Added, &reportPackage{pkg.GoPackagePath, pkg.Rev, pkg.Sha256})
+	}
+	for _, pkg := range diff.Removed {
+		r.Removed = append(r.Removed, &reportPackage{pkg.GoPackagePath, pkg.Rev, pkg.Sha256})
+	}
+	for _, update := range diff.Updated {
+		r.Updated = append(r.Updated, &reportUpdate{
+			GoPackagePath: update.New.GoPackagePath,
+			OldRev:        update.Old.Rev,
+			NewRev:        update.New.Rev,
+			OldSha256:     update.Old.Sha256,
+			NewSha256:     update.New.Sha256,
+		})
+	}
+	for _, result := range failed {
+		r.Failed = append(r.Failed, &reportFailure{result.ImportPath, result.Error.Error()})
+	}
+	return r
+}
+
+func (r *report) text() []byte {
+	var buf bytes.Buffer
+	section := func(title string, n int) {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "%s (%d):\n", title, n)
+	}
+
+	section("Added", len(r.Added))
+	for _, pkg := range r.Added {
+		fmt.Fprintf(&buf, "  %s %s\n", pkg.GoPackagePath, pkg.Rev)
+	}
+	section("Removed", len(r.Removed))
+	for _, pkg := range r.Removed {
+		fmt.Fprintf(&buf, "  %s %s\n", pkg.GoPackagePath, pkg.Rev)
+	}
+	section("Updated", len(r.Updated))
+	for _, update := range r.Updated {
+		fmt.Fprintf(&buf, "  %s %s -> %s", update.GoPackagePath, update.OldRev, update.NewRev)
+		if update.OldSha256 != update.NewSha256 {
+			fmt.Fprintf(&buf, " (sha256 %s -> %s)", update.OldSha256, update.NewSha256)
+		}
+		buf.WriteString("\n")
+	}
+	section("Failed", len(r.Failed))
+	for _, failure := range r.Failed {
+		fmt.Fprintf(&buf, "  %s: %s\n", failure.ImportPath, failure.Error)
+	}
+
+	return buf.Bytes()
+}
+
+// writeReport writes a summary of the changes made to deps.nix in this run, as
+// JSON if filePath ends in .json and as text otherwise.
+func writeReport(filePath string, diff *depsDiff, failed []*PackageResult) error {
+	r := newReport(diff, failed)
+
+	out := r.text()
+	if strings.HasSuffix(filePath, ".json") {
+		var err error
+		out, err = json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		out = append(out, '\n')
+	}
+
+	return os.WriteFile(filePath, out, 0644)
+}
+
+// writePrunedReport writes the goPackagePaths of the pruned packages to
+// filePath, one per line.
+func writePrunedReport(filePath string, pruned []*Package) error {
+	var buf bytes.Buffer
+	for _, pkg := range pruned {
+		fmt.Fprintln(&buf, pkg.GoPackagePath)
+	}
+	return os.WriteFile(filePath, buf.Bytes(), 0644)
+}
@@ -0,0 +1,86 @@
+package vgo2nix
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	prevDeps := map[string]*Package{
+		"github.com/example/removed": {GoPackagePath: "github.com/example/removed", Rev: "v1.0.0", Sha256: "0fq4k5239w29qv587a95ivzmdf5jnbhdmc4y2ny87nw3i3qmgsj4"},
+		"github.com/pkg/profile":     {GoPackagePath: "github.com/pkg/profile", Rev: "v1.2.0", Sha256: "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr"},
+		"golang.org/x/sys":           {GoPackagePath: "golang.org/x/sys", Rev: "d99a578cf41b", Sha256: "10q9xx4pmnq92qn6ff4xp7n1hx766wvw2rf7pqcd6rx5plgwz8cm"},
+	}
+	packages := []*Package{
+		{GoPackagePath: "github.com/pkg/errors", Rev: "v0.9.1", Sha256: "1761pybhc2kqr6v5fm8faj08x9bql8427yqg6vnfv6nhrasx1mwq"},
+		{GoPackagePath: "github.com/pkg/profile", Rev: "v1.2.1", Sha256: "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr"},
+		{GoPackagePath: "golang.org/x/sys", Rev: "d99a578cf41b", Sha256: "10q9xx4pmnq92qn6ff4xp7n1hx766wvw2rf7pqcd6rx5plgwz8cm"},
+	}
+	failed := []*PackageResult{
+		{ImportPath: "github.com/example/broken", Error: errors.New("fetch failed")},
+	}
+
+	expected := map[string]string{
+		"report.txt": `Added (1):
+  github.com/pkg/errors v0.9.1
+
+Removed (1):
+  github.com/example/removed v1.0.0
+
+Updated (1):
+  github.com/pkg/profile v1.2.0 -> v1.2.1 (sha256 11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr -> 0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr)
+
+Failed (1):
+  github.com/example/broken: fetch failed
+`,
+		"report.json": `{
+  "added": [
+    {
+      "goPackagePath": "github.com/pkg/errors",
+      "rev": "v0.9.1",
+      "sha256": "1761pybhc2kqr6v5fm8faj08x9bql8427yqg6vnfv6nhrasx1mwq"
+    }
+  ],
+  "removed": [
+    {
+      "goPackagePath": "github.com/example/removed",
+      "rev": "v1.0.0",
+      "sha256": "0fq4k5239w29qv587a95ivzmdf5jnbhdmc4y2ny87nw3i3qmgsj4"
+    }
+  ],
+  "updated": [
+    {
+      "goPackagePath": "github.com/pkg/profile",
+      "oldRev": "v1.2.0",
+      "newRev": "v1.2.1",
+      "oldSha256": "11jizr28kfkr6zscjxg95pqi6cjp08aqnhs41sdhc98nww78ilkr",
+      "newSha256": "0blqmvgqvdbqmh3fp9pfdxc9w1qfshrr0zy9whj0sn372bw64qnr"
+    }
+  ],
+  "failed": [
+    {
+      "importPath": "github.com/example/broken",
+      "error": "fetch failed"
+    }
+  ]
+}
+`,
+	}
+
+	dir := t.TempDir()
+	for name, report := range expected {
+		filePath := filepath.Join(dir, name)
+		if err := writeReport(filePath, diffPackages(prevDeps, packages), failed); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != report {
+			t.Errorf("%s is\n%s\nexpected\n%s", name, data, report)
+		}
+	}
+}
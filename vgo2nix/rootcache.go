@@ -0,0 +1,176 @@
+package vgo2nix
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// repoRootCache remembers the repository roots import paths resolved to.
+//
+// A root that the server reported for an import path below it, e.g.
+// golang.org/x/tools for golang.org/x/tools/gopls, is shared by every import
+// path below it, which saves a lookup for every further module of a vanity
+// import repository. A root equal to the import path it was looked up for
+// proves nothing about the paths below, which can be repositories of their
+// own like nested GitLab projects, so it only answers for that path.
+type repoRootCache struct {
+	// File the roots are kept in between runs, empty to keep them in memory
+	path string
+	mu   sync.Mutex
+	// Roots by the import path they were looked up for, and when
+	roots    map[string]*vcs.RepoRoot
+	resolved map[string]time.Time
+	// Roots shared by the import paths below them, by root
+	shared map[string]*vcs.RepoRoot
+	// Lookups of the same host run one at a time, so that a lookup can reuse
+	// the root an earlier one of a path next to it found
+	hosts map[string]*sync.Mutex
+}
+
+func newRepoRootCache() *repoRootCache {
+	return &repoRootCache{
+		roots:    make(map[string]*vcs.RepoRoot),
+		resolved: make(map[string]time.Time),
+		shared:   make(map[string]*vcs.RepoRoot),
+		hosts:    make(map[string]*sync.Mutex),
+	}
+}
+
+// repoRootMaxAge is how long a root read from the state directory is trusted.
+// Vanity import servers rarely move their repositories, but they do.
+const repoRootMaxAge = 7 * 24 * time.Hour
+
+type repoRootEntry struct {
+	Root     string    `json:"root"`
+	Repo     string    `json:"repo"`
+	VCS      string    `json:"vcs"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// loadRepoRootCache reads the roots resolved by earlier runs from filePath,
+// leaving out those older than repoRootMaxAge. A missing file is an empty
+// cache, and so is one that cannot be parsed.
+func loadRepoRootCache(filePath string) (*repoRootCache, error) {
+	c := newRepoRootCache()
+	c.path = filePath
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]*repoRootEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logf("Ignoring the repository roots %s, they cannot be parsed: %v", filePath, err)
+		return c, nil
+	}
+	for importPath, entry := range entries {
+		vcsCmd := vcs.ByCmd(entry.VCS)
+		if vcsCmd == nil || time.Since(entry.Resolved) > repoRootMaxAge {
+			continue
+		}
+		c.put(importPath, &vcs.RepoRoot{VCS: vcsCmd, Repo: entry.Repo, Root: entry.Root}, entry.Resolved)
+	}
+	return c, nil
+}
+
+// resolve returns the cached root of importPath, calling lookup and
+// remembering its root if there is none. Failed lookups are not remembered.
+func (c *repoRootCache) resolve(importPath string, lookup func(importPath string) (*vcs.RepoRoot, error)) (*vcs.RepoRoot, error) {
+	if c == nil {
+		return lookup(importPath)
+	}
+
+	host := c.hostLock(importPath)
+	host.Lock()
+	defer host.Unlock()
+
+	if repoRoot := c.get(importPath); repoRoot != nil {
+		return repoRoot, nil
+	}
+	repoRoot, err := lookup(importPath)
+	if err != nil {
+		return nil, err
+	}
+	c.put(importPath, repoRoot, time.Now())
+	return repoRoot, nil
+}
+
+func (c *repoRootCache) hostLock(importPath string) *sync.Mutex {
+	host := importPath
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lock, ok := c.hosts[host]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.hosts[host] = lock
+	}
+	return lock
+}
+
+// get returns the root importPath was looked up with, or else the longest
+// shared root containing it, or nil
+func (c *repoRootCache) get(importPath string) *vcs.RepoRoot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if repoRoot, ok := c.roots[importPath]; ok {
+		return repoRoot
+	}
+	for prefix := importPath; ; {
+		if repoRoot, ok := c.shared[prefix]; ok {
+			return repoRoot
+		}
+		i := strings.LastIndex(prefix, "/")
+		if i < 0 {
+			return nil
+		}
+		prefix = prefix[:i]
+	}
+}
+
+func (c *repoRootCache) put(importPath string, repoRoot *vcs.RepoRoot, resolved time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots[importPath] = repoRoot
+	c.resolved[importPath] = resolved
+	if strings.HasPrefix(importPath, repoRoot.Root+"/") {
+		c.shared[repoRoot.Root] = repoRoot
+	}
+}
+
+func (c *repoRootCache) save() error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make(map[string]*repoRootEntry, len(c.roots))
+	for importPath, repoRoot := range c.roots {
+		entries[importPath] = &repoRootEntry{
+			Root:     repoRoot.Root,
+			Repo:     repoRoot.Repo,
+			VCS:      repoRoot.VCS.Cmd,
+			Resolved: c.resolved[importPath].UTC(),
+		}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, append(data, '\n'))
+}
@@ -0,0 +1,182 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// sharedRootModules are modules of a few vanity import repositories
+var sharedRootModules = []string{
+	"golang.org/x/tools",
+	"golang.org/x/tools/gopls",
+	"golang.org/x/tools/cmd/auth",
+	"golang.org/x/exp",
+	"golang.org/x/exp/typeparams",
+	"k8s.io/api",
+	"k8s.io/apimachinery",
+	"k8s.io/client-go",
+	"k8s.io/client-go/tools",
+	"cloud.google.com/go",
+	"cloud.google.com/go/storage",
+	"cloud.google.com/go/pubsub",
+}
+
+// sharedRoots are the repository roots of sharedRootModules
+var sharedRoots = []string{
+	"golang.org/x/tools",
+	"golang.org/x/exp",
+	"k8s.io/api",
+	"k8s.io/apimachinery",
+	"k8s.io/client-go",
+	"cloud.google.com/go",
+}
+
+// countingLookup stands in for vcs.RepoRootForImportPath, answering with the
+// longest of roots containing the import path and counting the lookups
+func countingLookup(roots []string, lookups *int64) func(importPath string) (*vcs.RepoRoot, error) {
+	return func(importPath string) (*vcs.RepoRoot, error) {
+		atomic.AddInt64(lookups, 1)
+		var found string
+		for _, root := range roots {
+			if (importPath == root || strings.HasPrefix(importPath, root+"/")) && len(root) > len(found) {
+				found = root
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("unrecognized import path %q", importPath)
+		}
+		return &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://" + found, Root: found}, nil
+	}
+}
+
+func TestRepoRootCacheNested(t *testing.T) {
+	// A nested GitLab project is a repository of its own, looking up its
+	// parent first must not make it resolve to the parent
+	roots := []string{"gitlab.com/group/project", "gitlab.com/group/project/sub", "golang.org/x/tools"}
+	tests := []struct {
+		importPath string
+		root       string
+		lookedUp   bool
+	}{
+		{"gitlab.com/group/project", "gitlab.com/group/project", true},
+		{"gitlab.com/group/project/sub", "gitlab.com/group/project/sub", true},
+		{"gitlab.com/group/project", "gitlab.com/group/project", false},
+		// A root reported for a path below it is shared by the paths below
+		{"golang.org/x/tools/gopls", "golang.org/x/tools", true},
+		{"golang.org/x/tools/cmd/auth", "golang.org/x/tools", false},
+		{"golang.org/x/tools", "golang.org/x/tools", false},
+	}
+
+	var lookups int64
+	opts := &options{repoRoots: newRepoRootCache(), lookupRepoRoot: countingLookup(roots, &lookups)}
+	for _, test := range tests {
+		before := lookups
+		repoRoot, err := opts.resolveRepoRoot(test.importPath)
+		if err != nil {
+			t.Errorf("resolveRepoRoot(%q) failed: %v", test.importPath, err)
+			continue
+		}
+		if repoRoot.Root != test.root {
+			t.Errorf("resolveRepoRoot(%q) = %s, expected %s", test.importPath, repoRoot.Root, test.root)
+		}
+		if lookedUp := lookups > before; lookedUp != test.lookedUp {
+			t.Errorf("resolveRepoRoot(%q) looked the root up: %v, expected %v", test.importPath, lookedUp, test.lookedUp)
+		}
+	}
+}
+
+func TestRepoRootCacheConcurrent(t *testing.T) {
+	var lookups int64
+	opts := &options{repoRoots: newRepoRootCache(), lookupRepoRoot: countingLookup(sharedRoots, &lookups)}
+	var wg sync.WaitGroup
+	for _, importPath := range []string{"golang.org/x/tools/gopls", "golang.org/x/tools/cmd/auth", "golang.org/x/tools/go/vcs"} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(importPath string) {
+				defer wg.Done()
+				if _, err := opts.resolveRepoRoot(importPath); err != nil {
+					t.Error(err)
+				}
+			}(importPath)
+		}
+	}
+	wg.Wait()
+	if lookups != 1 {
+		t.Errorf("%d lookups of paths below one root, expected 1", lookups)
+	}
+}
+
+func BenchmarkRepoRootCache(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			var lookups int64
+			for i := 0; i < b.N; i++ {
+				opts := &options{lookupRepoRoot: countingLookup(sharedRoots, &lookups)}
+				if cached {
+					opts.repoRoots = newRepoRootCache()
+				}
+				for _, importPath := range sharedRootModules {
+					if _, err := opts.resolveRepoRoot(importPath); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(lookups)/float64(b.N), "lookups/op")
+		})
+	}
+}
+
+func TestRepoRootCacheSaved(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "roots.json")
+	c, err := loadRepoRootCache(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lookups int64
+	lookup := countingLookup(sharedRoots, &lookups)
+	for _, importPath := range []string{"golang.org/x/tools/gopls", "k8s.io/api"} {
+		if _, err := c.resolve(importPath, lookup); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A root resolved long ago is left out when read again
+	c.put("golang.org/x/exp", &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://golang.org/x/exp", Root: "golang.org/x/exp"}, time.Now().Add(-repoRootMaxAge-time.Hour))
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = loadRepoRootCache(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookups = 0
+	for _, importPath := range []string{"golang.org/x/tools/cmd/auth", "k8s.io/api", "golang.org/x/exp"} {
+		if _, err := c.resolve(importPath, lookup); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("%d lookups after reading the saved roots, expected 1 of golang.org/x/exp", lookups)
+	}
+
+	if err := os.WriteFile(filePath, []byte(`{"k8s.io/api": {"root"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = loadRepoRootCache(filePath); err != nil {
+		t.Errorf("Reading cut off roots failed: %v", err)
+	} else if len(c.roots) != 0 {
+		t.Errorf("Read %d roots from a cut off file, expected none", len(c.roots))
+	}
+}
@@ -0,0 +1,59 @@
+package vgo2nix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+type repoRootInfo struct {
+	ImportPath string `json:"importPath"`
+	Root       string `json:"root"`
+	Repo       string `json:"repo"`
+	VCS        string `json:"vcs"`
+}
+
+// printRepoRootsOf prints how the import path of every module maps to a
+// repository, which separates resolution problems from fetch problems.
+func printRepoRootsOf(ctx context.Context, opts *options, asJSON bool) error {
+	entries, err := getModules(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]*repoRootInfo, 0, len(entries))
+	for _, entry := range entries {
+		repoRoot, err := opts.resolveRepoRoot(entry.downloadPath())
+		if err != nil {
+			if !opts.keepGoing {
+				return err
+			}
+			logf("Encountered error: %v", err)
+			continue
+		}
+		infos = append(infos, &repoRootInfo{
+			ImportPath: entry.importPath,
+			Root:       repoRoot.Root,
+			Repo:       repoRoot.Repo,
+			VCS:        repoRoot.VCS.Cmd,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ImportPath < infos[j].ImportPath
+	})
+
+	if asJSON {
+		out, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	for _, info := range infos {
+		fmt.Printf("%s %s %s %s\n", info.ImportPath, info.Root, info.VCS, info.Repo)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package vgo2nix
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// sharedFetches lets modules at the same rev of the same repository share a
+// single fetch. The first module claiming a fetch runs it, the others wait
+// for its result.
+type sharedFetches struct {
+	mu      sync.Mutex
+	fetches map[string]*sharedFetch
+}
+
+type sharedFetch struct {
+	done chan struct{}
+	pkg  *Package
+	err  error
+}
+
+func newSharedFetches() *sharedFetches {
+	return &sharedFetches{fetches: make(map[string]*sharedFetch)}
+}
+
+// claim returns the fetch of key and whether the caller is the first to
+// claim it, in which case it has to publish the result.
+func (s *sharedFetches) claim(key string) (*sharedFetch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fetch, ok := s.fetches[key]; ok {
+		return fetch, false
+	}
+	fetch := &sharedFetch{done: make(chan struct{})}
+	s.fetches[key] = fetch
+	return fetch, true
+}
+
+func (f *sharedFetch) publish(pkg *Package, err error) {
+	f.pkg, f.err = pkg, err
+	close(f.done)
+}
+
+// wait returns the result of the fetch once it is published
+func (f *sharedFetch) wait(ctx context.Context) (*Package, error) {
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.pkg == nil && f.err == nil {
+		// The fetching module panicked
+		return nil, fmt.Errorf("The fetch did not finish")
+	}
+	return f.pkg, f.err
+}
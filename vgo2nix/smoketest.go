@@ -0,0 +1,79 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// smokeTestExpr fetches the dependencies the same way a build would, entries
+// for fetchTree are fetched during evaluation and wrapped in a derivation.
+const smokeTestExpr = `{ depsFile }:
+let
+  pkgs = import <nixpkgs> {};
+  deps = if pkgs.lib.hasSuffix ".json" (toString depsFile)
+    then pkgs.lib.importJSON depsFile
+    else import depsFile;
+  # Entries written with --sri carry hash instead of sha256
+  hashOf = fetch: if fetch ? hash then { inherit (fetch) hash; } else { inherit (fetch) sha256; };
+  fetch = dep:
+    if dep.fetch.type == "FromGitHub" then pkgs.fetchFromGitHub ({
+      inherit (dep.fetch) owner repo rev;
+    } // hashOf dep.fetch)
+    else if dep.fetch.type == "hg" then pkgs.fetchhg ({
+      inherit (dep.fetch) url rev;
+    } // hashOf dep.fetch)
+    else if dep.fetch.type == "bzr" then pkgs.fetchbzr ({
+      inherit (dep.fetch) url rev;
+    } // hashOf dep.fetch)
+    else if dep.fetch.type == "zip" then pkgs.fetchzip ({
+      inherit (dep.fetch) url;
+    } // hashOf dep.fetch)
+    else if dep.fetch ? narHash then pkgs.writeText "source" (builtins.fetchTree {
+      inherit (dep.fetch) type url rev narHash;
+    }).outPath
+    else pkgs.fetchgit ({
+      inherit (dep.fetch) url rev;
+      fetchSubmodules = dep.fetch.fetchSubmodules or true;
+      fetchLFS = dep.fetch.fetchLFS or false;
+      leaveDotGit = dep.fetch.leaveDotGit or false;
+      deepClone = dep.fetch.deepClone or false;
+      branchName = dep.fetch.branchName or null;
+      sparseCheckout = dep.fetch.sparseCheckout or [ ];
+    } // hashOf dep.fetch);
+in map fetch deps
+`
+
+// smokeTest builds the fetches of all packages. If that fails every package
+// is built on its own to find the first failing one.
+func smokeTest(ctx context.Context, depsFile string, packages []*Package) error {
+	depsFile, err := filepath.Abs(depsFile)
+	if err != nil {
+		return err
+	}
+
+	build := func(attr ...string) (string, error) {
+		args := []string{"--no-out-link", "--arg", "depsFile", depsFile, "-E", smokeTestExpr}
+		args = append(args, attr...)
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "nix-build", args...)
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		return strings.TrimSpace(stderr.String()), err
+	}
+
+	logf("Smoke testing %s", depsFile)
+	if _, err := build(); err == nil {
+		return nil
+	}
+	for i, pkg := range packages {
+		if stderr, err := build("-A", strconv.Itoa(i)); err != nil {
+			return fmt.Errorf("Smoke test failed for %s: %v\n%s", pkg.GoPackagePath, err, stderr)
+		}
+	}
+	return fmt.Errorf("Smoke test failed, but every package builds on its own")
+}
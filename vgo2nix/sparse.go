@@ -0,0 +1,21 @@
+package vgo2nix
+
+// sparseCheckout returns the directory of a module in its repository if only
+// that directory is checked out, which matches if either its module path or
+// its goPackagePath matches a glob. Modules at the root of their repository
+// need all of it.
+func (opts *options) sparseCheckout(modulePath string, goPackagePath string) string {
+	if !matchesModule(opts.sparseGlobs, modulePath, goPackagePath) {
+		return ""
+	}
+	return moduleSubdir(goPackagePath, modulePath)
+}
+
+// sparseCacheFetcher extends the fetcher hashes are cached under, a sparse
+// checkout only hashes the directory it is limited to.
+func sparseCacheFetcher(cacheFetcher string, sparse string) string {
+	if sparse != "" {
+		return cacheFetcher + "+sparse:" + sparse
+	}
+	return cacheFetcher
+}
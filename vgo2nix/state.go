@@ -0,0 +1,86 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// stateVersion is the format of the state directory. Bump it whenever the
+// format of any file in it changes incompatibly, older state is discarded.
+const stateVersion = 1
+
+const stateVersionFile = "VERSION"
+
+// stateDir holds everything vgo2nix keeps between runs
+type stateDir struct {
+	dir string
+}
+
+func defaultStateDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vgo2nix")
+}
+
+// openStateDir prepares the state directory, discarding it if reset is set or
+// if it was written in a different format.
+func openStateDir(dir string, reset bool) (*stateDir, error) {
+	if reset {
+		logf("Resetting state in %s", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	version, err := readStateVersion(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != stateVersion {
+		if version != 0 {
+			logf("Discarding state in %s of format %d, expected format %d", dir, version, stateVersion)
+			if err := os.RemoveAll(dir); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, stateVersionFile), []byte(fmt.Sprintf("%d\n", stateVersion)), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return &stateDir{dir: dir}, nil
+}
+
+// readStateVersion returns the format of the state in dir, or 0 if there is none.
+func readStateVersion(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, stateVersionFile))
+	if os.IsNotExist(err) {
+		// The hash cache predates the version file and is in format 1
+		if _, err := os.Stat(filepath.Join(dir, "hashes.json")); err == nil {
+			return 1, nil
+		}
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		// Unreadable state is as good as outdated state
+		return -1, nil
+	}
+	return version, nil
+}
+
+func (s *stateDir) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
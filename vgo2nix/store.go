@@ -0,0 +1,75 @@
+package vgo2nix
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var hexRev = regexp.MustCompile(`^[a-f0-9]*$`)
+
+func nixStoreDir() string {
+	if dir := os.Getenv("NIX_STORE_DIR"); dir != "" {
+		return dir
+	}
+	return "/nix/store"
+}
+
+// urlToName mirrors the default derivation name nixpkgs' fetchgit picks:
+// https://github.com/NixOS/nixpkgs/blob/4bb072f0a8b267613c127684e099a70e1f6ff106/pkgs/build-support/fetchgit/default.nix#L3-L13
+func urlToName(url string, rev string) string {
+	base := path.Base(strings.TrimSuffix(url, "/"))
+	base = base[strings.LastIndex(base, ":")+1:]
+	base = strings.TrimSuffix(base, ".git")
+	if hexRev.MatchString(rev) {
+		short := rev
+		if len(short) > 7 {
+			short = short[:7]
+		}
+		base += "-" + short
+	}
+	return base
+}
+
+// fixedOutputPath computes the store path of a recursive sha256 fixed-output
+// derivation, which only depends on the store directory, the derivation name
+// and the hash.
+func fixedOutputPath(name string, sha256Hash string) (string, error) {
+	hash, err := nixBase32Decode(sha256Hash)
+	if err != nil {
+		return "", err
+	}
+	storeDir := nixStoreDir()
+	fingerprint := sha256.Sum256([]byte(fmt.Sprintf("source:sha256:%x:%s:%s", hash, storeDir, name)))
+
+	compressed := make([]byte, 20)
+	for i, b := range fingerprint {
+		compressed[i%20] ^= b
+	}
+
+	return filepath.Join(storeDir, nixBase32Encode(compressed)+"-"+name), nil
+}
+
+// inStore reports whether the output of fetching pkg is already present in the
+// local Nix store.
+func inStore(pkg *Package) bool {
+	name := "source"
+	switch pkg.Fetcher {
+	case fetcherFetchgit:
+		name = urlToName(pkg.URL, pkg.Rev)
+	case fetcherFetchhg:
+		name = "hg-archive"
+	case fetcherFetchbzr:
+		name = "bzr-export"
+	}
+	storePath, err := fixedOutputPath(name, pkg.Sha256)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(storePath)
+	return err == nil
+}
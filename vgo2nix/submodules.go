@@ -0,0 +1,54 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var submoduleCloneFailed = regexp.MustCompile(`clone of '([^']+)' into submodule path '([^']+)' failed`)
+
+// submoduleError points at the submodule if a prefetch failed because one of
+// the submodules could not be cloned, which is otherwise easily mistaken for
+// a problem with the module itself.
+func submoduleError(err error) error {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return nil
+	}
+	match := submoduleCloneFailed.FindStringSubmatch(string(exitErr.Stderr))
+	if match == nil {
+		return nil
+	}
+	return fmt.Errorf("Fetching submodule %s from %s failed (use --submodule-url-rewrite to fetch it from elsewhere, or --no-submodules)", match[2], match[1])
+}
+
+// skipsSubmodules reports whether a module is fetched without its submodules,
+// for modules with big or dead ones that are not needed to build it
+func (opts *options) skipsSubmodules(modulePath string, goPackagePath string) bool {
+	return opts.noSubmodules || matchesModule(opts.noSubmodulesGlobs, modulePath, goPackagePath)
+}
+
+// submodulesCacheFetcher extends the fetcher hashes are cached under, the
+// submodules are part of the hash.
+func submodulesCacheFetcher(cacheFetcher string, noSubmodules bool) string {
+	if noSubmodules {
+		return cacheFetcher + "+nosubmodules"
+	}
+	return cacheFetcher
+}
+
+// submoduleRewriteConfig turns from=to rewrites into git configuration, which
+// git applies to the URLs of submodules when cloning them.
+func submoduleRewriteConfig(rewrites []string) ([]string, error) {
+	var config []string
+	for _, rewrite := range rewrites {
+		parts := strings.SplitN(rewrite, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid submodule URL rewrite \"%s\", expected from=to", rewrite)
+		}
+		config = append(config, fmt.Sprintf("url.%s.insteadOf=%s", parts[1], parts[0]))
+	}
+	return config, nil
+}
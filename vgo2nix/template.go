@@ -0,0 +1,107 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// defaultEntryTemplate renders the deps.nix entries buildGoPackage reads
+const defaultEntryTemplate = `{{range .Comments}}  {{.}}
+{{end}}  {
+    goPackagePath = {{nixString .GoPackagePath}};
+{{- if .Date}}
+    date = {{nixString .Date}};
+{{- end}}
+    fetch = {{.Fetch}};
+  }`
+
+// templateFuncs are the functions templates can call besides the built-in
+// ones: nixString quotes a string for Nix
+var templateFuncs = template.FuncMap{"nixString": nixString}
+
+var defaultTemplate = template.Must(template.New("entry").Funcs(templateFuncs).Parse(defaultEntryTemplate))
+
+// templateEntry is what the template of a deps.nix entry renders
+type templateEntry struct {
+	GoPackagePath string
+	URL           string
+	Rev           string
+	// base32 sha256, even with --sri
+	Sha256 string
+	// fetch type as written to the fetch attribute set, e.g. git
+	Type   string
+	Commit string
+	// Whether fetchgit has to fetch submodules for the hash to match
+	FetchSubmodules bool
+	// Commit date, only set with --annotate-date
+	Date     string
+	Comments []string
+	// The rendered fetch attribute set, or the name of the let binding
+	// holding it with --dedupe-output
+	Fetch string
+	// The module and its version as listed by go
+	ModulePath string
+	Version    string
+}
+
+// loadTemplate parses the entry template in filePath and renders a made up
+// entry with it, so that mistakes show up before anything is fetched
+func loadTemplate(filePath string) (*template.Template, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filePath).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, &templateEntry{
+		GoPackagePath: "example.com/module",
+		URL:           "https://example.com/module",
+		Rev:           "v1.0.0",
+		Type:          "git",
+		Fetch:         "{ }",
+		ModulePath:    "example.com/module",
+		Version:       "v1.0.0",
+	}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// formatPackage renders the deps.nix entry of a package. fetch is either the
+// rendered fetch attribute set or the name of a let binding holding it.
+func formatPackage(pkg *Package, fetch string, opts *options) (string, error) {
+	tmpl := opts.template
+	if tmpl == nil {
+		tmpl = defaultTemplate
+	}
+	entry := &templateEntry{
+		GoPackagePath: pkg.GoPackagePath,
+		URL:           pkg.URL,
+		Rev:           pkg.Rev,
+		Sha256:        pkg.Sha256,
+		Type:          fetchType(pkg.Fetcher),
+		Commit:        pkg.Commit,
+		// fetchTree and fetchFromGitHub never fetch submodules
+		FetchSubmodules: pkg.Fetcher == fetcherFetchgit && !pkg.NoSubmodules,
+		Comments:        pkg.Comments,
+		Fetch:           fetch,
+		ModulePath:      pkg.ModulePath,
+		Version:         pkg.Version,
+	}
+	if opts.annotateDate {
+		entry.Date = pkg.Date
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, entry); err != nil {
+		return "", fmt.Errorf("Rendering the entry of %s: %v", pkg.GoPackagePath, err)
+	}
+	// Entries are joined by newlines, templates from files end in one
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,69 @@
+package vgo2nix
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// fetchTiming is how long fetching a module took, including retries and the
+// fallback revs tried after the first failed.
+type fetchTiming struct {
+	path     string
+	url      string
+	duration time.Duration
+	failed   bool
+}
+
+// fetchTimings collects the durations of all fetches of a run. A nil
+// fetchTimings records nothing.
+type fetchTimings struct {
+	mu      sync.Mutex
+	timings []fetchTiming
+}
+
+func newFetchTimings() *fetchTimings {
+	return &fetchTimings{}
+}
+
+func (t *fetchTimings) record(timing fetchTiming) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings = append(t.timings, timing)
+}
+
+// slowest returns the n longest fetches, longest first, and the number of
+// fetches recorded
+func (t *fetchTimings) slowest(n int) ([]fetchTiming, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timings := append([]fetchTiming(nil), t.timings...)
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].duration > timings[j].duration
+	})
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings, len(t.timings)
+}
+
+// logSlowest logs the n longest fetches with their repository URLs
+func (t *fetchTimings) logSlowest(n int) {
+	slowest, total := t.slowest(n)
+	if len(slowest) == 0 {
+		logf("No modules were fetched")
+		return
+	}
+	logf("Slowest %d of %d fetches:", len(slowest), total)
+	for _, timing := range slowest {
+		status := ""
+		if timing.failed {
+			status = ", failed"
+		}
+		logEventf(&logEvent{Event: "fetch_time", Path: timing.path, URL: timing.url, Seconds: timing.duration.Seconds()},
+			"  %s %s (%s%s)", timing.duration.Round(time.Millisecond), timing.path, timing.url, status)
+	}
+}
@@ -0,0 +1,62 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nixErrorPos matches the position nix reports errors at, in the form
+// "at /path/deps.nix:12:5" of both older and newer versions
+var nixErrorPos = regexp.MustCompile(`at (.+?):(\d+):(\d+)`)
+
+// validateNix parses the file at tmpPath, about to be written to filePath,
+// with nix-instantiate --parse. A syntax error names the entry it is in.
+func validateNix(tmpPath string, filePath string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("nix-instantiate", "--parse", tmpPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Temporary file names are of no use in messages
+		message := strings.ReplaceAll(strings.TrimSpace(stderr.String()), tmpPath, filePath)
+		if message == "" {
+			message = err.Error()
+		}
+		if entry := invalidEntry(tmpPath, stderr.String()); entry != "" {
+			message += "\n" + entry
+		}
+		return fmt.Errorf("%s does not parse as Nix, nothing was written:\n%s", filePath, message)
+	}
+	return nil
+}
+
+// invalidEntry describes the line of tmpPath the nix error message points
+// at, and the goPackagePath of the entry it is in if there is one above it
+func invalidEntry(tmpPath string, message string) string {
+	var lineNum int
+	for _, m := range nixErrorPos.FindAllStringSubmatch(message, -1) {
+		if m[1] == tmpPath {
+			lineNum, _ = strconv.Atoi(m[2])
+			break
+		}
+	}
+	data, err := os.ReadFile(tmpPath)
+	if lineNum < 1 || err != nil {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if lineNum > len(lines) {
+		return ""
+	}
+
+	for i := lineNum - 1; i >= 0; i-- {
+		if m := goPackagePathAttr.FindStringSubmatch(strings.TrimSpace(lines[i])); m != nil {
+			return fmt.Sprintf("In the entry of %s, line %d: %s", unescapeNixString(m[1]), lineNum, strings.TrimSpace(lines[lineNum-1]))
+		}
+	}
+	return fmt.Sprintf("Line %d: %s", lineNum, strings.TrimSpace(lines[lineNum-1]))
+}
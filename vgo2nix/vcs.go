@@ -0,0 +1,120 @@
+package vgo2nix
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Repositories of other version control systems than git are always fetched
+// with the fetcher of their system, whatever --fetcher says.
+const (
+	fetcherFetchhg  = "fetchhg"
+	fetcherFetchbzr = "fetchbzr"
+)
+
+// fetchType is the type attribute of the entries of a fetcher, the one
+// buildGoPackage dispatches on.
+func fetchType(fetcher string) string {
+	switch fetcher {
+	case fetcherFetchhg:
+		return "hg"
+	case fetcherFetchbzr:
+		return "bzr"
+	case fetcherGitHub:
+		return "FromGitHub"
+	case fetcherProxy:
+		return "zip"
+	}
+	return "git"
+}
+
+// vcsOfFetcher is the inverse of fetcherFor for the version control system,
+// module zips from a proxy have none.
+func vcsOfFetcher(fetcher string) string {
+	switch fetcher {
+	case fetcherFetchhg:
+		return "hg"
+	case fetcherFetchbzr:
+		return "bzr"
+	case fetcherProxy:
+		return ""
+	}
+	return "git"
+}
+
+// fetcherFor returns the fetcher for a repository given the command of its
+// version control system as reported by vcs.RepoRootForImportPath. Git
+// repositories not hosted on GitHub are fetched with fetchgit when emitting
+// fetchFromGitHub entries. With the proxy fetcher every module is fetched
+// from the proxy.
+func (opts *options) fetcherFor(url string, vcsCmd string) (string, error) {
+	if opts.fetcher == fetcherProxy {
+		return fetcherProxy, nil
+	}
+
+	switch vcsCmd {
+	case "hg":
+		return fetcherFetchhg, nil
+	case "bzr":
+		return fetcherFetchbzr, nil
+	case "git":
+	default:
+		return "", fmt.Errorf("Unsupported version control system %s", vcsCmd)
+	}
+
+	if opts.fetcher == fetcherGitHub {
+		if _, _, ok := githubRepo(url); !ok {
+			return fetcherFetchgit, nil
+		}
+	}
+	return opts.fetcher, nil
+}
+
+// normalizeRepoURL canonicalizes the repository URLs of hosts whose lookups
+// return forms nix-prefetch-git and fetchgit trip over: Bitbucket and
+// Sourcehut are always fetched over https without a trailing slash, and git
+// repositories on Bitbucket with the .git suffix. URLs of other hosts are
+// kept as they are.
+func normalizeRepoURL(repoURL string, vcsCmd string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.User != nil {
+		return repoURL
+	}
+	switch u.Hostname() {
+	case "bitbucket.org", "git.sr.ht", "hg.sr.ht":
+	default:
+		return repoURL
+	}
+
+	u.Scheme = "https"
+	u.Path = strings.TrimRight(u.Path, "/")
+	if u.Hostname() == "bitbucket.org" && vcsCmd == "git" && !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+	return u.String()
+}
+
+var bzrPseudoRev = regexp.MustCompile(`^[0-9]{12}$`)
+
+// bzrRev turns the zero padded revision number of a bzr pseudo-version into
+// the revision number bzr understands.
+func bzrRev(rev string) string {
+	if !bzrPseudoRev.MatchString(rev) {
+		return rev
+	}
+	return strings.TrimLeft(rev, "0")
+}
+
+// parsePrintedHash turns the output of the prefetchers that print the hash
+// on their first line (nix-prefetch-url, nix-prefetch-hg and nix-prefetch-bzr)
+// into the shape of the nix-prefetch-git output.
+func parsePrintedHash(out []byte) (map[string]interface{}, error) {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	sha256 := strings.TrimSpace(lines[0])
+	if sha256 == "" {
+		return nil, fmt.Errorf("No hash printed")
+	}
+	return map[string]interface{}{"sha256": sha256}, nil
+}
@@ -0,0 +1,58 @@
+package vgo2nix
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// vendoredModules parses vendor/modules.txt and returns the version of every
+// module with packages in the vendor tree, by module path. The version of a
+// replaced module is the one of its replacement, modules replaced by a local
+// directory have none. Modules only listed because go.mod requires them
+// explicitly have no package lines and are left out.
+func vendoredModules(filePath string) (map[string]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vendored := make(map[string]string)
+	var modulePath, version string
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "## "), strings.TrimSpace(line) == "":
+			// Annotations like "## explicit; go 1.17"
+		case strings.HasPrefix(line, "# "):
+			// # path [version] [=> replacement [version]]
+			fields := strings.Fields(strings.TrimPrefix(line, "# "))
+			arrow := len(fields)
+			for i, field := range fields {
+				if field == "=>" {
+					arrow = i
+				}
+			}
+			if arrow == 0 || arrow > 2 || (arrow == len(fields) && arrow != 2) || len(fields)-arrow > 3 {
+				return nil, fmt.Errorf("Malformed module line %d in %s: %s", lineNo, filePath, line)
+			}
+			modulePath, version = fields[0], ""
+			if replacement := fields[arrow:]; len(replacement) == 0 {
+				version = fields[1]
+			} else if len(replacement) == 3 {
+				version = replacement[2]
+			}
+		default:
+			// A vendored package of the module above
+			if modulePath == "" {
+				return nil, fmt.Errorf("Package %s on line %d of %s belongs to no module", line, lineNo, filePath)
+			}
+			vendored[modulePath] = version
+		}
+	}
+
+	return vendored, scanner.Err()
+}
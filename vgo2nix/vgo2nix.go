@@ -0,0 +1,1477 @@
+package vgo2nix // import "github.com/adisbladis/vgo2nix/vgo2nix"
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/tools/go/vcs"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Package struct {
+	GoPackagePath string
+	URL           string
+	Rev           string
+	Sha256        string
+	Fetcher       string
+	FetchLFS      bool
+	LeaveDotGit   bool
+	DeepClone     bool
+	BranchName    string
+	// Fetched without submodules, written as fetchSubmodules = false
+	NoSubmodules bool
+	// The directory of the repository checked out alone, empty for all of it
+	SparseCheckout string
+	// The commit Rev resolved to, only recorded with --record-commit
+	Commit string
+	// Commit date as reported by nix-prefetch-git
+	Date string
+	// Comment lines of the entry in the input file, written back above it
+	Comments []string
+	// The mirror the source was fetched from if it is not URL, not part of
+	// deps.nix
+	FetchURL string
+
+	// The module and its version as listed by go, not part of deps.nix
+	ModulePath string
+	Version    string
+	// The module replacing it, only written to gomod2nix.toml
+	ReplacePath string
+}
+
+type PackageResult struct {
+	ImportPath string
+	Package    *Package
+	Error      error
+}
+
+type options struct {
+	// Directory of the module to list, empty for the current one
+	dir        string
+	keepGoing  bool
+	numJobs    int
+	fetcher    string
+	storeCheck bool
+	gitConfig  []string
+	// Module paths excluded like the main module
+	mainModules []string
+	toolchain   string
+	hashCache   *hashCache
+	// Modules listed before, nil to not keep them, and whether to reuse them
+	// instead of running go
+	moduleLists *moduleListCache
+	offline     bool
+	// Module proxy to fetch module zips from for the proxy fetcher
+	proxyURL string
+	// -mod flag of go list, empty lets go pick
+	modMode string
+	// Only list the modules in vendor/modules.txt, at the versions it records
+	vendored bool
+	// File with the output of go list -json -m all to read instead of running go
+	modulesJSON string
+	// Directories of more modules to merge, relative to dir
+	moreDirs []string
+	// Use the highest version of modules listed at several versions in the
+	// directories rather than failing
+	resolveHighest bool
+	// Hashes fetched longer ago than this are fetched again, zero means forever
+	maxAge time.Duration
+	// Modules whose hashes are always fetched again
+	refresh []string
+	// Only use known hashes and never touch the network for fetching
+	frozen bool
+	// Only reuse the entries of the input file, not even the hash cache
+	freeze bool
+	// module@rev or module@version pairs that may resolve to an empty tree
+	allowEmpty []string
+	// Adapts the number of concurrent fetches up to numJobs
+	adaptive *adaptiveLimiter
+	// Stops fetching from hosts that appear to be down, nil to never stop
+	hostBreaker *hostBreaker
+	// Emit the commit date of every entry
+	annotateDate bool
+	format       string
+	// Write hash = "sha256-..." instead of base32 sha256 attributes
+	sri bool
+	// Comment lines written below the header of deps.nix
+	headerMetadata []string
+	// Order of the entries in the output, sortPath or sortURL
+	sortBy string
+	// Bind fetches shared by several entries once
+	dedupe bool
+	// Renders every entry of deps.nix, nil for the default format
+	template *template.Template
+	// Globs of modules whose git-lfs content is fetched
+	lfs []string
+	// Keep .git or the whole history of all modules or of those matching a glob
+	leaveDotGit      bool
+	leaveDotGitGlobs []string
+	deepClone        bool
+	deepCloneGlobs   []string
+	// Fetch all modules or those matching a glob without submodules
+	noSubmodules      bool
+	noSubmodulesGlobs []string
+	// Globs of modules of which only their directory is checked out
+	sparseGlobs []string
+	// Branches to fetch the rev of a module from, by module path
+	branchHints map[string]string
+	// Repositories of import path prefixes, instead of asking their server
+	repoMappings []repoMapping
+	// Mirrors to fetch repositories from, and whether to write the URLs of
+	// the repositories rather than of their mirrors
+	mirrors         []mirror
+	emitOriginalURL bool
+	// Roots resolved so far, nil to ask the server of every import path
+	repoRoots *repoRootCache
+	// Asks the server of an import path for its root, vcs.RepoRootForImportPath
+	// if nil
+	lookupRepoRoot func(importPath string) (*vcs.RepoRoot, error)
+	// Fail on import paths resolving to a root outside of them, rather than warn
+	strictRoots bool
+	// Only list the modules needed to build this package
+	forPackage string
+	// Hosts whose tags may lack the v prefix of versions
+	stripVPrefix []string
+	// Only fetch modules without an entry in the input file
+	onlyFailed bool
+	// Only fetch these modules, keeping the entries of all others
+	only    []string
+	retries int
+	// Permitted module@version pins, nil permits everything
+	allowedVersions map[string]bool
+	// Globs of the hosts modules may be fetched from, nil permits every host
+	allowedHosts []string
+	// go.sum hashes to verify fetched checkouts against, nil skips verification
+	goSums map[string]string
+	// Stop short of fetching anything and report what would be fetched
+	dryRun bool
+	// Prefetches running longer than this are killed, zero means never
+	fetchTimeout time.Duration
+	// Globs of modules to leave out
+	exclude []string
+	// Revs and hashes to use instead of fetching, by module path
+	overrides map[string]*configOverride
+	// Record the commit every rev resolved to
+	recordCommit bool
+	// go binary to list modules with, go from PATH if empty
+	goBinary string
+	// Module path patterns of GOPRIVATE and GONOSUMDB, fetched over SSH
+	privatePatterns string
+	netrc           string
+	// Command run instead of nix-prefetch-git, empty for nix-prefetch-git
+	prefetchCmd string
+	// Computes the hashes instead of the prefetch commands if set
+	prefetcher Prefetcher
+	// Parse the written deps.nix with nix-instantiate before replacing the
+	// previous one
+	validate bool
+	// Durations of the fetches, nil to not record them
+	timings *fetchTimings
+	// onResult is called with every result as it arrives, done of total
+	onResult func(result *PackageResult, done int, total int)
+}
+
+// fresh reports whether a cached hash is young enough to be trusted
+func (opts *options) fresh(entry *hashCacheEntry) bool {
+	if opts.maxAge == 0 {
+		return true
+	}
+	return entry != nil && time.Since(entry.Fetched) <= opts.maxAge
+}
+
+// commitOf returns the commit to record for an entry, none unless
+// --record-commit is given.
+func (opts *options) commitOf(commit string) string {
+	if !opts.recordCommit {
+		return ""
+	}
+	return commit
+}
+
+// stripsVPrefix reports whether tags of the repo at url may lack the v prefix
+func (opts *options) stripsVPrefix(repoURL string) bool {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return false
+	}
+	for _, host := range opts.stripVPrefix {
+		if u.Hostname() == host {
+			return true
+		}
+	}
+	return false
+}
+
+type modEntry struct {
+	importPath string
+	version    string
+	rev        string
+	// Module path of the replacement, empty unless replaced by another module
+	replacePath string
+}
+
+// downloadPath is the module path go downloads the module from
+func (entry *modEntry) downloadPath() string {
+	if entry.replacePath != "" {
+		return entry.replacePath
+	}
+	return entry.importPath
+}
+
+// exitTimedOut is the exit status when --max-runtime is exceeded, the same one
+// timeout(1) uses.
+const exitTimedOut = 124
+
+const (
+	fetcherFetchgit  = "fetchgit"
+	fetcherFetchTree = "fetchtree"
+)
+
+var fullCommitRev = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+var semverTag = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+`)
+
+// revMatches reports whether a previously resolved rev refers to rev. Entries
+// written for fetchTree carry the full commit hash while go.mod only has the
+// abbreviated one from the pseudo-version, and tags of hosts given to
+// --strip-v-prefix may lack the v of the version.
+func revMatches(prevRev string, rev string) bool {
+	if prevRev == rev || semverTag.MatchString(rev) && prevRev == strings.TrimPrefix(rev, "v") {
+		return true
+	}
+	// Modules in a subdirectory are fetched at tags prefixed with it
+	if semverTag.MatchString(rev) && strings.HasSuffix(prevRev, "/"+rev) {
+		return true
+	}
+	return fullCommitRev.MatchString(prevRev) && len(rev) >= 7 && strings.HasPrefix(prevRev, rev)
+}
+
+// goToolchain picks the go binary and environment to list modules with. The
+// toolchain directive from go.mod is used unless overridden, since the
+// toolchain version affects module graph pruning and pseudo-versions. go
+// only downloads the toolchain of the directive if the local one is older,
+// --toolchain always switches to it. The go binary is opts.goBinary, go from
+// PATH by default.
+func (opts *options) goToolchain() (string, []string, error) {
+	goBinary := opts.goBinary
+	if goBinary == "" {
+		goBinary = "go"
+	}
+	toolchain := opts.toolchain
+	fromGoMod := toolchain == ""
+	if fromGoMod {
+		var err error
+		toolchain, err = goModDirective(filepath.Join(opts.dir, "go.mod"), "toolchain")
+		if err != nil && !os.IsNotExist(err) {
+			return "", nil, err
+		}
+	}
+
+	switch toolchain {
+	case "":
+		return goBinary, nil, nil
+	case "local":
+		return goBinary, []string{"GOTOOLCHAIN=local"}, nil
+	}
+
+	// Toolchains installed through golang.org/dl are named after the version
+	if _, err := exec.LookPath(toolchain); err == nil {
+		return toolchain, []string{"GOTOOLCHAIN=local"}, nil
+	}
+	// Downloading a toolchain fails offline and in the Nix sandbox
+	if local := localGoVersion(goBinary); fromGoMod && local != "" && compareGoVersions(local, toolchain) >= 0 {
+		logf("Listing modules with the local %s, not older than toolchain %s of go.mod", local, toolchain)
+		return goBinary, []string{"GOTOOLCHAIN=local"}, nil
+	}
+	logf("Toolchain %s not found in PATH, relying on go to switch to it (requires go 1.21 or newer)", toolchain)
+	return goBinary, []string{"GOTOOLCHAIN=" + toolchain}, nil
+}
+
+// localGoVersion returns the version of goBinary itself, like go1.22.0, or
+// an empty string if it cannot be run or is too old to tell.
+func localGoVersion(goBinary string) string {
+	cmd := exec.Command(goBinary, "env", "GOVERSION")
+	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// goVersionParts matches go versions like go1.21, go1.21.0 and go1.22rc1,
+// with anything after them such as +auto or a custom suffix ignored
+var goVersionParts = regexp.MustCompile(`^go(\d+)\.(\d+)(?:\.(\d+))?(?:(rc|beta)(\d+))?`)
+
+// compareGoVersions orders two go versions, go1.21rc1 coming before
+// go1.21.0. Versions that are not go versions are the lowest.
+func compareGoVersions(a string, b string) int {
+	ka, kb := goVersionKey(a), goVersionKey(b)
+	for i := range ka {
+		if ka[i] < kb[i] {
+			return -1
+		} else if ka[i] > kb[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// goVersionKey returns the major, minor and patch version of a go version,
+// followed by its kind of release (beta, rc or final) and pre-release number
+func goVersionKey(version string) [5]int {
+	m := goVersionParts.FindStringSubmatch(version)
+	if m == nil {
+		return [5]int{-1}
+	}
+	var key [5]int
+	key[0], _ = strconv.Atoi(m[1])
+	key[1], _ = strconv.Atoi(m[2])
+	key[2], _ = strconv.Atoi(m[3])
+	switch m[4] {
+	case "beta":
+		key[3] = 0
+	case "rc":
+		key[3] = 1
+	default:
+		key[3] = 2
+	}
+	key[4], _ = strconv.Atoi(m[5])
+	return key
+}
+
+type goModReplacement struct {
+	// A module path, or a directory for replacements with a local copy
+	Path    string
+	Version string
+}
+
+// goMod is a module as printed by go list -json -m
+type goMod struct {
+	Path    string
+	Main    bool
+	Version string
+	Replace *goModReplacement
+}
+
+// isToolchainModule reports whether mod stands for the go command or its
+// toolchain rather than a dependency, as listed by recent go versions.
+func isToolchainModule(mod goMod) bool {
+	return mod.Path == "go" || mod.Path == "toolchain" || strings.HasPrefix(mod.Version, "go")
+}
+
+// decodeModules reads the JSON stream of go list -json -m all and returns
+// the modules to fetch. Main modules, including the ones in isMain, toolchain
+// entries and local replacements are left out; isMain records which of its
+// modules were seen. Malformed entries fail decoding, or are skipped with
+// keepGoing.
+func decodeModules(r io.Reader, isMain map[string]bool, keepGoing bool) ([]goMod, error) {
+	var mods []goMod
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxJSONValue)
+	scanner.Split(scanJSONValues)
+	for scanner.Scan() {
+		var mod goMod
+		if err := json.Unmarshal(scanner.Bytes(), &mod); err != nil {
+			if !keepGoing {
+				return nil, fmt.Errorf("Malformed module entry: %v\n%s", err, quoteJSONValue(scanner.Bytes()))
+			}
+			logf("Skipping malformed module entry: %v\n%s", err, quoteJSONValue(scanner.Bytes()))
+			continue
+		}
+
+		if isToolchainModule(mod) {
+			logf("Skipping toolchain entry %s %s", mod.Path, mod.Version)
+			continue
+		}
+
+		if mod.Replace != nil {
+			// Local copies have no version and come with the source tree
+			if mod.Replace.Version == "" {
+				logf("Skipping local replace for %s", mod.Path)
+				continue
+			}
+			mod.Version = mod.Replace.Version
+		}
+
+		if _, ok := isMain[mod.Path]; ok {
+			isMain[mod.Path] = true
+			continue
+		}
+
+		if !mod.Main {
+			mods = append(mods, mod)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mods, nil
+}
+
+// readModulesJSON decodes the output of go list -json -m all captured in a
+// file, for when go cannot be run.
+func readModulesJSON(filePath string, isMain map[string]bool, keepGoing bool) ([]goMod, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mods, err := decodeModules(f, isMain, keepGoing)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading %s: %v", filePath, err)
+	}
+	return mods, nil
+}
+
+// goListModules lists the modules with go list -json -m all, pruned to the
+// ones opts.forPackage needs if it is set.
+func goListModules(ctx context.Context, opts *options, isMain map[string]bool) ([]goMod, error) {
+	goBinary, goEnv, err := opts.goToolchain()
+	if err != nil {
+		return nil, err
+	}
+
+	// In a workspace go lists the modules needed by all of its modules, with
+	// every module at the highest version any of them requires.
+	modMode := opts.modMode
+	workFile, err := goWorkFile(ctx, opts.dir, goBinary, goEnv)
+	if err != nil {
+		return nil, err
+	}
+	if workFile != "" {
+		if modMode == "mod" {
+			return nil, fmt.Errorf("--mod=mod is not supported in workspace %s, set GOWORK=off to list the module alone", workFile)
+		}
+		// Workspaces refuse -mod=mod, which may well be set in GOFLAGS
+		if modMode == "" {
+			modMode = "readonly"
+		}
+		logf("Listing modules of workspace %s", workFile)
+	}
+	// go picks -mod=vendor for vendored modules by itself, with which it
+	// refuses to list all modules
+	if opts.vendored && modMode == "" {
+		modMode = "readonly"
+	}
+
+	// Both change what go lists, so they are worth seeing in the log
+	for _, name := range []string{"GOFLAGS", "GOTOOLCHAIN"} {
+		if value := os.Getenv(name); value != "" && !hasEnvVar(goEnv, name) {
+			logf("Listing modules with %s=%s from the environment", name, value)
+		}
+	}
+
+	args := []string{"list", "-json", "-m"}
+	if modMode != "" {
+		args = append(args, "-mod="+modMode)
+	}
+	args = append(args, "all")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, goBinary, args...)
+	cmd.Dir = opts.dir
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"GO111MODULE=on",
+	)
+	cmd.Env = append(cmd.Env, goEnv...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	mods, decodeErr := decodeModules(stdout, isMain, opts.keepGoing)
+	if decodeErr != nil {
+		// Let go exit rather than block on writing the rest
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	// A truncated stream is most likely explained by go failing
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("'go list -m all' failed with %s:\n%s", err, stderr.String())
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	if opts.forPackage != "" {
+		needed, err := packageModules(ctx, opts.dir, goBinary, goEnv, modMode, opts.forPackage)
+		if err != nil {
+			return nil, err
+		}
+		var pruned []goMod
+		for _, mod := range mods {
+			if needed[mod.Path] {
+				pruned = append(pruned, mod)
+			}
+		}
+		logf("%s needs %d of %d modules", opts.forPackage, len(pruned), len(mods))
+		mods = pruned
+	}
+
+	return mods, nil
+}
+
+func getModules(ctx context.Context, opts *options) ([]*modEntry, error) {
+	isMain := make(map[string]bool)
+	for _, path := range opts.mainModules {
+		isMain[path] = false
+	}
+
+	merged := make(map[string]*modEntry)
+	listedIn := make(map[string]string)
+	for i, dir := range opts.moduleDirs() {
+		dirOpts := opts
+		if i > 0 {
+			logf("Listing modules of %s", dir)
+			withDir := *opts
+			withDir.dir = dir
+			dirOpts = &withDir
+		}
+		entries, err := listModules(ctx, dirOpts, isMain)
+		if err != nil {
+			return nil, err
+		}
+		if err := mergeModules(merged, listedIn, entries, dirOpts.dirName(), opts.resolveHighest); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range opts.mainModules {
+		if !isMain[path] {
+			return nil, fmt.Errorf("Main module %s is not in the module graph", path)
+		}
+	}
+
+	// Keep the order of the logs below independent of go list
+	var entries []*modEntry
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].importPath < entries[j].importPath
+	})
+	for _, entry := range entries {
+		logEventf(&logEvent{Event: "module", Path: entry.importPath, Rev: entry.rev}, "goPackagePath %s has rev %s", entry.importPath, entry.rev)
+	}
+
+	return entries, nil
+}
+
+// listModules lists the modules of the project in opts.dir, recording the
+// main modules seen in isMain.
+func listModules(ctx context.Context, opts *options, isMain map[string]bool) ([]*modEntry, error) {
+	var mods []goMod
+	var err error
+	switch {
+	case opts.modulesJSON != "":
+		mods, err = readModulesJSON(opts.modulesJSON, isMain, opts.keepGoing)
+	case opts.offline:
+		mods, err = opts.moduleLists.load(opts, isMain)
+	default:
+		mods, err = goListModules(ctx, opts, isMain)
+		if err == nil && opts.moduleLists != nil {
+			if err := opts.moduleLists.store(opts, mods, isMain); err != nil {
+				logf("Failed writing the module list cache: %v", err)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.vendored {
+		modulesTxt := filepath.Join(opts.dir, "vendor", "modules.txt")
+		vendored, err := vendoredModules(modulesTxt)
+		if err != nil {
+			return nil, fmt.Errorf("--vendored needs vendor/modules.txt: %v", err)
+		}
+		var pruned []goMod
+		for _, mod := range mods {
+			if version, ok := vendored[mod.Path]; ok {
+				if version != "" {
+					mod.Version = version
+				}
+				pruned = append(pruned, mod)
+			}
+		}
+		logf("vendor/modules.txt has %d of %d modules", len(pruned), len(mods))
+		mods = pruned
+	}
+
+	var entries []*modEntry
+	for _, mod := range mods {
+		entry := &modEntry{
+			importPath: mod.Path,
+			version:    mod.Version,
+			rev:        versionRev(mod.Version),
+		}
+		if mod.Replace != nil && mod.Replace.Path != mod.Path {
+			entry.replacePath = mod.Replace.Path
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// missingModules returns the paths that are not part of the module graph
+func missingModules(entries []*modEntry, paths []string) []string {
+	inGraph := make(map[string]bool)
+	for _, entry := range entries {
+		inGraph[entry.importPath] = true
+	}
+
+	var missing []string
+	for _, path := range paths {
+		if !inGraph[path] {
+			missing = append(missing, path)
+		}
+	}
+	return missing
+}
+
+// lookupPrevDep finds the previous entry whose goPackagePath is the longest
+// prefix of importPath. This avoids resolving the repo root over the network.
+func lookupPrevDep(prevDeps map[string]*Package, importPath string) *Package {
+	var found *Package
+	for goPackagePath, pkg := range prevDeps {
+		if importPath != goPackagePath && !strings.HasPrefix(importPath, goPackagePath+"/") {
+			continue
+		}
+		if found == nil || len(goPackagePath) > len(found.GoPackagePath) {
+			found = pkg
+		}
+	}
+	return found
+}
+
+// frozenPackages resolves every module from prevDeps or the hash cache only,
+// or from prevDeps alone with opts.freeze, and fails listing all modules whose
+// hash would have to be fetched.
+func frozenPackages(entries []*modEntry, opts *options, prevDeps map[string]*Package) ([]*Package, []*PackageResult, error) {
+	pkgsMap := make(map[string]*Package)
+	var missing []*modEntry
+	for _, entry := range entries {
+		prevPkg := lookupPrevDep(prevDeps, entry.importPath)
+		if prevPkg == nil {
+			missing = append(missing, entry)
+			continue
+		}
+
+		// Entries of module zips do not know the repository of their module
+		if (prevPkg.Fetcher == fetcherProxy) != (opts.fetcher == fetcherProxy) {
+			missing = append(missing, entry)
+			continue
+		}
+
+		pkg := *prevPkg
+		fetcher, err := opts.fetcherFor(prevPkg.URL, vcsOfFetcher(prevPkg.Fetcher))
+		if err != nil {
+			return nil, nil, err
+		}
+		if override := opts.overrides[entry.importPath]; override != nil {
+			if opts.freeze && (override.Rev != prevPkg.Rev || override.Sha256 != prevPkg.Sha256) {
+				missing = append(missing, entry)
+				continue
+			}
+			pkg.Rev = override.Rev
+			pkg.Sha256 = override.Sha256
+			pkg.Fetcher = fetcher
+			pkg.ModulePath = entry.importPath
+			pkg.Version = entry.version
+			pkgsMap[pkg.GoPackagePath] = &pkg
+			continue
+		}
+		lfs := opts.fetchesLFS(entry.importPath, prevPkg.GoPackagePath)
+		dotGit := fetcher == fetcherFetchgit && opts.keepsDotGit(entry.importPath, prevPkg.GoPackagePath)
+		deep := fetcher == fetcherFetchgit && opts.deepClones(entry.importPath, prevPkg.GoPackagePath)
+		noSubs := fetcher == fetcherFetchgit && opts.skipsSubmodules(entry.importPath, prevPkg.GoPackagePath)
+		sparse := ""
+		if fetcher == fetcherFetchgit {
+			sparse = opts.sparseCheckout(entry.importPath, prevPkg.GoPackagePath)
+		}
+		rev, url := entry.rev, prevPkg.URL
+		if fetcher == fetcherProxy {
+			// Every version of a module is a zip of its own
+			rev = entry.version
+			url, err = proxyZipURL(opts.proxyURL, entry.importPath, entry.version)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if !revMatches(prevPkg.Rev, rev) || prevPkg.Fetcher != fetcher || prevPkg.FetchLFS != lfs || prevPkg.LeaveDotGit != dotGit || prevPkg.DeepClone != deep || prevPkg.NoSubmodules != noSubs || prevPkg.SparseCheckout != sparse {
+			if opts.freeze {
+				missing = append(missing, entry)
+				continue
+			}
+			cached := opts.hashCache.get(submodulesCacheFetcher(sparseCacheFetcher(dotGitCacheFetcher(lfsCacheFetcher(fetcher, lfs), dotGit, deep), sparse), noSubs), url, rev)
+			if cached == nil {
+				missing = append(missing, entry)
+				continue
+			}
+			pkg.URL = url
+			pkg.Rev = cached.Rev
+			pkg.Sha256 = cached.Sha256
+			pkg.Fetcher = fetcher
+			pkg.FetchLFS = lfs
+			pkg.LeaveDotGit = dotGit
+			pkg.DeepClone = deep
+			pkg.NoSubmodules = noSubs
+			pkg.SparseCheckout = sparse
+		}
+		pkg.ModulePath = entry.importPath
+		pkg.Version = entry.version
+		pkgsMap[pkg.GoPackagePath] = &pkg
+	}
+
+	if len(missing) > 0 {
+		var lines []string
+		for _, entry := range missing {
+			lines = append(lines, fmt.Sprintf("frozen: %s at %s not in lock", entry.importPath, entry.rev))
+		}
+		return nil, nil, errors.New(strings.Join(lines, "\n"))
+	}
+
+	return sortPackages(pkgsMap), nil, nil
+}
+
+// PrefetchCommand returns the prefetcher Generate runs for req without a
+// Prefetcher and its arguments: nix-prefetch-git, nix-prefetch-url for
+// tarballs, nix-prefetch-hg or nix-prefetch-bzr.
+func PrefetchCommand(req PrefetchRequest) (string, []string) {
+	switch req.Fetcher {
+	case fetcherGitHub, fetcherProxy:
+		return "nix-prefetch-url", []string{"--unpack", req.URL}
+	case fetcherFetchhg:
+		return "nix-prefetch-hg", []string{req.URL, req.Rev}
+	case fetcherFetchbzr:
+		return "nix-prefetch-bzr", []string{req.URL, req.Rev}
+	}
+
+	// The options for nix-prefetch-git need to match how buildGoPackage
+	// calls fetchgit:
+	// https://github.com/NixOS/nixpkgs/blob/8d8e56824de52a0c7a64d2ad2c4ed75ed85f446a/pkgs/development/go-modules/generic/default.nix#L54-L56
+	// and fetchgit's defaults:
+	// https://github.com/NixOS/nixpkgs/blob/8d8e56824de52a0c7a64d2ad2c4ed75ed85f446a/pkgs/build-support/fetchgit/default.nix#L15-L23
+	// fetchTree on the other hand does not fetch submodules by default.
+	args := []string{"--quiet"}
+	if req.FetchSubmodules {
+		args = append(args, "--fetch-submodules")
+	}
+	if req.FetchLFS {
+		args = append(args, "--fetch-lfs")
+	}
+	if req.LeaveDotGit {
+		args = append(args, "--leave-dotGit")
+	}
+	if req.DeepClone {
+		args = append(args, "--deepClone")
+	}
+	if req.BranchName != "" {
+		args = append(args, "--branch-name", req.BranchName)
+	}
+	if req.SparseCheckout != "" {
+		args = append(args, "--sparse-checkout", req.SparseCheckout)
+	}
+	args = append(args, "--url", req.URL, "--rev", req.Rev)
+	return "nix-prefetch-git", args
+}
+
+// keepPrevPackages adds the entries that already have a package in prevDeps
+// to pkgsMap as they are and returns the remaining ones.
+func keepPrevPackages(entries []*modEntry, prevDeps map[string]*Package, pkgsMap map[string]*Package) []*modEntry {
+	var missing []*modEntry
+	for _, entry := range entries {
+		prevPkg := lookupPrevDep(prevDeps, entry.importPath)
+		if prevPkg == nil {
+			missing = append(missing, entry)
+			continue
+		}
+		pkg := *prevPkg
+		pkg.ModulePath = entry.importPath
+		pkg.Version = entry.version
+		pkgsMap[pkg.GoPackagePath] = &pkg
+	}
+	return missing
+}
+
+// retryDelay is the time to wait before retrying a failed fetch, doubling
+// with every attempt.
+func retryDelay(attempt int) time.Duration {
+	return time.Second << uint(attempt)
+}
+
+// resolveRepoRoot finds the repository of an import path, from the repo
+// mappings if one matches, from the gopkg.in naming scheme and by asking the
+// import path's server otherwise.
+func (opts *options) resolveRepoRoot(importPath string) (*vcs.RepoRoot, error) {
+	repoRoot := mappedRepoRoot(opts.repoMappings, importPath)
+	if repoRoot == nil {
+		if repo, root, _ := gopkgInRepo(importPath); repo != "" {
+			// gopkg.in redirects to GitHub, fetching from there avoids the redirect
+			repoRoot = &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: repo, Root: root}
+		} else {
+			lookup := opts.lookupRepoRoot
+			if lookup == nil {
+				lookup = func(importPath string) (*vcs.RepoRoot, error) {
+					return vcs.RepoRootForImportPath(importPath, false)
+				}
+			}
+			var err error
+			repoRoot, err = opts.repoRoots.resolve(importPath, lookup)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// A root outside of the import path means a misconfigured vanity import,
+	// the repository would not contain the module
+	if repoRoot.Root != importPath && !strings.HasPrefix(importPath, repoRoot.Root+"/") {
+		err := fmt.Errorf("%s resolved to the repository root %s (%s), which does not contain it", importPath, repoRoot.Root, repoRoot.Repo)
+		if opts.strictRoots {
+			return nil, err
+		}
+		logf("Warning: %v", err)
+	}
+	return repoRoot, nil
+}
+
+// getPackages returns the packages of all modules along with the results of
+// modules that failed under keepGoing.
+func getPackages(ctx context.Context, opts *options, prevDeps map[string]*Package) ([]*Package, []*PackageResult, error) {
+	entries, err := getModules(ctx, opts)
+	if err != nil {
+		// Not wrapped with %w, there is nothing to write if listing timed out
+		return nil, nil, fmt.Errorf("Failed listing modules: %v", err)
+	}
+
+	if opts.allowedVersions != nil {
+		if err := checkVersionAllowlist(entries, opts.allowedVersions); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(opts.exclude) > 0 {
+		entries = excludeModules(entries, opts.exclude)
+	}
+
+	env, err := prefetchEnv(opts.gitConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.netrc != "" {
+		env = netrcEnv(env, opts.netrc)
+	}
+
+	if missing := missingModules(entries, opts.refresh); len(missing) > 0 {
+		return nil, nil, fmt.Errorf("Modules to refresh not in the module graph: %s", strings.Join(missing, ", "))
+	}
+	if missing := missingModules(entries, opts.only); len(missing) > 0 {
+		return nil, nil, fmt.Errorf("Modules given to --only not in the module graph: %s", strings.Join(missing, ", "))
+	}
+	if opts.frozen {
+		return frozenPackages(entries, opts, prevDeps)
+	}
+
+	allowEmpty := make(map[string]bool)
+	for _, moduleRev := range opts.allowEmpty {
+		allowEmpty[moduleRev] = true
+	}
+
+	refresh := make(map[string]bool)
+	for _, path := range opts.refresh {
+		refresh[path] = true
+	}
+	for _, path := range opts.only {
+		refresh[path] = true
+	}
+
+	fetches := newSharedFetches()
+
+	processEntry := func(entry *modEntry) (pkg *Package, err error) {
+		wrapError := func(err error) error {
+			return fmt.Errorf("Error processing import path \"%s\": %w", entry.importPath, err)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, wrapError(err)
+		}
+
+		// Modules replaced by another one are fetched from the repository of
+		// the replacement, repoRootPath is the root of that and modulePath the
+		// path of the replacement in it
+		modulePath := entry.downloadPath()
+		var goPackagePath, repoRootPath, repoURL, fetchURL, fetcher string
+		if opts.fetcher == fetcherProxy {
+			if entry.replacePath != "" {
+				return nil, wrapError(fmt.Errorf("it is replaced by %s, which the %s fetcher does not support", entry.replacePath, fetcherProxy))
+			}
+			// Module zips contain just the module, there is no repository to resolve
+			goPackagePath = entry.importPath
+			repoRootPath = entry.importPath
+			fetcher = fetcherProxy
+			repoURL, err = proxyZipURL(opts.proxyURL, entry.importPath, entry.version)
+			if err != nil {
+				return nil, wrapError(err)
+			}
+			proxyEntry := *entry
+			proxyEntry.rev = entry.version
+			entry = &proxyEntry
+		} else {
+			var repoRoot *vcs.RepoRoot
+			repoRoot, err = opts.resolveRepoRoot(modulePath)
+			if err != nil {
+				return nil, wrapError(err)
+			}
+			repoRootPath = repoRoot.Root
+			goPackagePath = repoRoot.Root
+			if entry.replacePath != "" {
+				goPackagePath, err = replacedPackagePath(entry.importPath, entry.replacePath, repoRoot.Root)
+				if err != nil {
+					return nil, wrapError(err)
+				}
+				logf("%s is replaced by %s, fetching it from %s", entry.importPath, entry.replacePath, repoRoot.Repo)
+			}
+			repoURL = normalizeRepoURL(repoRoot.Repo, repoRoot.VCS.Cmd)
+			if opts.allowedHosts != nil && !hostAllowed(repoURL, opts.allowedHosts) {
+				return nil, wrapError(fmt.Errorf("its repository %s is on %s, which is not an allowed host", repoURL, repoHost(repoURL)))
+			}
+			fetcher, err = opts.fetcherFor(repoRoot.Repo, repoRoot.VCS.Cmd)
+			if err != nil {
+				return nil, wrapError(err)
+			}
+			if fetcher != fetcherGitHub && vcsOfFetcher(fetcher) == "git" && matchPrefixPatterns(opts.privatePatterns, goPackagePath) {
+				if sshURL := sshRepoURL(repoURL); sshURL != "" {
+					repoURL = sshURL
+				}
+			}
+		}
+		// Hosts are configured by the URL of the repository, not its mirror
+		originalURL := repoURL
+		fetchURL = opts.mirrorURL(repoURL)
+		if !opts.emitOriginalURL {
+			repoURL = fetchURL
+		}
+		if fetcher == fetcherFetchbzr {
+			bzrEntry := *entry
+			bzrEntry.rev = bzrRev(entry.rev)
+			entry = &bzrEntry
+		}
+		lfs := fetcher == fetcherFetchgit && opts.fetchesLFS(entry.importPath, goPackagePath)
+		dotGit := fetcher == fetcherFetchgit && opts.keepsDotGit(entry.importPath, goPackagePath)
+		deep := fetcher == fetcherFetchgit && opts.deepClones(entry.importPath, goPackagePath)
+		noSubs := fetcher == fetcherFetchgit && opts.skipsSubmodules(entry.importPath, goPackagePath)
+		sparse := ""
+		if fetcher == fetcherFetchgit {
+			sparse = opts.sparseCheckout(entry.importPath, goPackagePath)
+		}
+		branch := ""
+		if vcsOfFetcher(fetcher) == "git" {
+			branch = opts.branchHints[entry.importPath]
+		}
+		// Hashes with and without LFS content, .git or submodules, or of a
+		// part of the checkout, are cached separately
+		cacheFetcher := submodulesCacheFetcher(sparseCacheFetcher(dotGitCacheFetcher(lfsCacheFetcher(fetcher, lfs), dotGit, deep), sparse), noSubs)
+
+		if override := opts.overrides[entry.importPath]; override != nil {
+			logf("Overriding %s with rev %s", goPackagePath, override.Rev)
+			return &Package{
+				GoPackagePath:  goPackagePath,
+				URL:            repoURL,
+				Rev:            override.Rev,
+				Sha256:         override.Sha256,
+				Fetcher:        fetcher,
+				FetchLFS:       lfs,
+				LeaveDotGit:    dotGit,
+				DeepClone:      deep,
+				NoSubmodules:   noSubs,
+				BranchName:     branch,
+				SparseCheckout: sparse,
+			}, nil
+		}
+
+		if refresh[entry.importPath] {
+			logf("Refreshing %s", goPackagePath)
+		} else if prevPkg, ok := prevDeps[goPackagePath]; ok {
+			if prevPkg.Fetcher == fetcher && prevPkg.FetchLFS == lfs && prevPkg.LeaveDotGit == dotGit && prevPkg.DeepClone == deep && prevPkg.NoSubmodules == noSubs && prevPkg.BranchName == branch && prevPkg.SparseCheckout == sparse && revMatches(prevPkg.Rev, entry.rev) {
+				// The age of a hash from deps.nix is only known if it went through the cache
+				if opts.fresh(opts.hashCache.get(cacheFetcher, prevPkg.URL, entry.rev)) {
+					// A mirror has the same hash as the repository it mirrors
+					if prevPkg.URL != repoURL && opts.mirrorURL(prevPkg.URL) == fetchURL {
+						pkg := *prevPkg
+						pkg.URL = repoURL
+						return &pkg, nil
+					}
+					return prevPkg, nil
+				}
+				logf("Revalidating %s", goPackagePath)
+			} else if prevPkg.Fetcher == fetcher && prevPkg.FetchLFS == lfs && prevPkg.LeaveDotGit == dotGit && prevPkg.DeepClone == deep && prevPkg.NoSubmodules == noSubs && prevPkg.BranchName == branch && prevPkg.SparseCheckout == sparse && opts.fresh(opts.hashCache.get(cacheFetcher, prevPkg.URL, prevPkg.Rev)) && opts.sameCommit(ctx, env, prevPkg, repoURL, entry.rev) {
+				logf("Reusing %s, %s is at the same commit as %s", goPackagePath, entry.rev, prevPkg.Rev)
+				pkg := *prevPkg
+				// fetchTree entries have the full commit as rev already
+				if fetcher != fetcherFetchTree {
+					pkg.Rev = entry.rev
+				}
+				if pkg.Commit == "" && fullCommitRev.MatchString(prevPkg.Rev) {
+					pkg.Commit = opts.commitOf(prevPkg.Rev)
+				}
+				return &pkg, nil
+			}
+		}
+
+		// The same repo and rev may be known under another path, in which case the
+		// hash can be trusted if the fetch result is already in the store.
+		if opts.storeCheck && !refresh[entry.importPath] {
+			for _, prevPkg := range prevDeps {
+				if prevPkg.URL != repoURL || prevPkg.Fetcher != fetcher || prevPkg.FetchLFS != lfs || prevPkg.LeaveDotGit != dotGit || prevPkg.DeepClone != deep || prevPkg.NoSubmodules != noSubs || prevPkg.BranchName != branch || prevPkg.SparseCheckout != sparse || !revMatches(prevPkg.Rev, entry.rev) {
+					continue
+				}
+				if inStore(prevPkg) {
+					logf("Reusing %s from store", goPackagePath)
+					pkg := *prevPkg
+					pkg.GoPackagePath = goPackagePath
+					return &pkg, nil
+				}
+			}
+		}
+
+		if cached := opts.hashCache.get(cacheFetcher, repoURL, entry.rev); cached != nil && opts.fresh(cached) && !refresh[entry.importPath] {
+			return &Package{
+				GoPackagePath:  goPackagePath,
+				URL:            repoURL,
+				Rev:            cached.Rev,
+				Sha256:         cached.Sha256,
+				Fetcher:        fetcher,
+				FetchLFS:       lfs,
+				LeaveDotGit:    dotGit,
+				DeepClone:      deep,
+				NoSubmodules:   noSubs,
+				BranchName:     branch,
+				SparseCheckout: sparse,
+				Commit:         opts.commitOf(cached.Commit),
+				Date:           cached.Date,
+			}, nil
+		}
+
+		if opts.dryRun {
+			return nil, &wouldFetchError{goPackagePath: goPackagePath, rev: entry.rev}
+		}
+
+		// Modules at the same rev of the same repository are fetched once,
+		// unless every checkout has to be verified against go.sum
+		fetchKey := strings.Join([]string{cacheFetcher, repoURL, entry.rev, branch}, " ")
+		if opts.goSums != nil {
+			fetchKey += " " + entry.importPath
+		}
+		fetch, first := fetches.claim(fetchKey)
+		if !first {
+			shared, err := fetch.wait(ctx)
+			if err != nil {
+				return nil, wrapError(fmt.Errorf("Fetching %s at %s along with another module failed: %w", repoURL, entry.rev, err))
+			}
+			logf("Reusing the fetch of %s for %s", shared.GoPackagePath, goPackagePath)
+			reused := *shared
+			reused.GoPackagePath = goPackagePath
+			return &reused, nil
+		}
+		start := time.Now()
+		defer func() {
+			fetch.publish(pkg, err)
+			opts.timings.record(fetchTiming{path: goPackagePath, url: repoURL, duration: time.Since(start), failed: err != nil})
+		}()
+
+		logEventf(&logEvent{Event: "fetch_start", Path: goPackagePath, Rev: entry.rev}, "Fetching %s", goPackagePath)
+		prefetch := func(rev string) (map[string]interface{}, error) {
+			if err := opts.hostBreaker.check(fetchURL); err != nil {
+				return nil, err
+			}
+			if opts.adaptive != nil {
+				opts.adaptive.acquire()
+				defer opts.adaptive.release()
+			}
+			var out []byte
+			var err error
+			req := PrefetchRequest{
+				Fetcher:         fetcher,
+				URL:             fetchURL,
+				Rev:             rev,
+				FetchSubmodules: fetcher == fetcherFetchgit && !noSubs,
+				FetchLFS:        lfs,
+				LeaveDotGit:     dotGit,
+				DeepClone:       deep,
+				BranchName:      branch,
+				SparseCheckout:  sparse,
+			}
+			if fetcher == fetcherGitHub {
+				owner, repo, _ := githubRepo(fetchURL)
+				req.URL = githubArchiveURL(owner, repo, rev)
+			}
+			if opts.prefetcher != nil {
+				var sha256 string
+				sha256, err = opts.prefetcher.Fetch(req)
+				out = []byte(sha256)
+			} else {
+				prefetcher, args := PrefetchCommand(req)
+				out, err = runPrefetch(ctx, opts.fetchTimeout, env, opts.prefetchTool(prefetcher), args...)
+			}
+			if err != nil {
+				var classified *prefetchError
+				if !errors.As(err, &classified) {
+					classified = classifyPrefetchError(err)
+				}
+				if ctx.Err() == nil {
+					// Only failures that may be caused by load count against the concurrency
+					opts.adaptive.recordError(classified)
+					opts.hostBreaker.record(fetchURL, classified)
+				}
+				return nil, classified
+			}
+			if ctx.Err() == nil {
+				opts.adaptive.record(false)
+				opts.hostBreaker.record(fetchURL, nil)
+			}
+
+			var resp map[string]interface{}
+			if opts.prefetcher != nil || fetcher == fetcherGitHub || fetcher == fetcherProxy || fetcher == fetcherFetchhg || fetcher == fetcherFetchbzr {
+				resp, err = parsePrintedHash(out)
+			} else {
+				err = json.Unmarshal(out, &resp)
+			}
+			// Output the prefetcher did not use to print is reported as it is
+			if err == nil {
+				_, err = base32OfPrinted(printedHash(resp))
+			}
+			if err == nil && fetcher == fetcherFetchTree {
+				if commit, _ := resp["rev"].(string); !fullCommitRev.MatchString(commit) {
+					err = fmt.Errorf("The prefetcher reported no commit")
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%v, its output was:\n%s", err, bytes.TrimSpace(out))
+			}
+			return resp, nil
+		}
+		// fetchAt fetches rev, retrying transient failures, and rejects empty trees
+		fetchAt := func(rev string) (map[string]interface{}, error) {
+			var prefetchErr *prefetchError
+			resp, err := prefetch(rev)
+			for attempt := 0; errors.As(err, &prefetchErr) && prefetchErr.transient() && attempt < opts.retries && ctx.Err() == nil; attempt++ {
+				delay := retryDelay(attempt)
+				logf("Fetching %s failed, retrying in %s: %v", goPackagePath, delay, err)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
+				resp, err = prefetch(rev)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			printed := printedHash(resp)
+			sha256, err := base32OfPrinted(printed)
+			if err != nil {
+				return nil, err
+			}
+			resp["sha256"] = sha256
+			done := &logEvent{Event: "fetch_done", Path: goPackagePath, Rev: rev, Sha256: sha256}
+			if opts.timings != nil {
+				done.Seconds = time.Since(start).Seconds()
+			}
+			logEventf(done, "Finished fetching %s", goPackagePath)
+
+			if isEmptyTree(printed) && !allowEmpty[entry.importPath+"@"+entry.version] && !allowEmpty[entry.importPath+"@"+entry.rev] {
+				if err := checkEmptyTree(resp, rev); err != nil {
+					return nil, &prefetchError{
+						kind:   prefetchEmptyTree,
+						err:    fmt.Errorf("Bad SHA256 for repo %s with rev %s: %v", fetchURL, rev, err),
+						detail: emptyTreeHint,
+					}
+				}
+			}
+			return resp, nil
+		}
+
+		// Modules in a subdirectory of their repository are tagged with the
+		// subdirectory as prefix, a plain tag of the same version belongs to
+		// the module at the root
+		fetchRev := entry.rev
+		tag := ""
+		if vcsOfFetcher(fetcher) == "git" {
+			tag = subdirTag(repoRootPath, modulePath, entry.version)
+		}
+		if tag != "" {
+			fetchRev = tag
+		}
+		var prefetchErr *prefetchError
+		resp, err := fetchAt(fetchRev)
+		if tag != "" && errors.As(err, &prefetchErr) && (prefetchErr.kind == prefetchRevNotFound || prefetchErr.kind == prefetchEmptyTree) && ctx.Err() == nil {
+			logf("Fetching %s at %s failed, trying %s", goPackagePath, fetchRev, entry.rev)
+			fetchRev = entry.rev
+			resp, err = fetchAt(fetchRev)
+		}
+		if errors.As(err, &prefetchErr) && prefetchErr.kind == prefetchRevNotFound && ctx.Err() == nil && opts.stripsVPrefix(originalURL) && semverTag.MatchString(entry.rev) {
+			fetchRev = strings.TrimPrefix(entry.rev, "v")
+			logf("Fetching %s at %s failed, trying %s", goPackagePath, entry.rev, fetchRev)
+			resp, err = fetchAt(fetchRev)
+		}
+		// gopkg.in serves the vN branch when the repository has no tag of
+		// the version, which is pinned to the commit it was at
+		if _, _, gopkgBranch := gopkgInRepo(modulePath); gopkgBranch != "" && semverTag.MatchString(entry.rev) && (fetcher == fetcherFetchgit || fetcher == fetcherFetchTree) && errors.As(err, &prefetchErr) && (prefetchErr.kind == prefetchRevNotFound || prefetchErr.kind == prefetchEmptyTree) && ctx.Err() == nil {
+			logf("Fetching %s at %s failed, trying branch %s", goPackagePath, fetchRev, gopkgBranch)
+			resp, err = fetchAt("refs/heads/" + gopkgBranch)
+			if err == nil {
+				if commit, _ := resp["rev"].(string); fullCommitRev.MatchString(commit) {
+					fetchRev = commit
+				} else {
+					err = fmt.Errorf("nix-prefetch-git reported no commit for branch %s of %s", gopkgBranch, fetchURL)
+				}
+			}
+		}
+		if err != nil {
+			if subErr := submoduleError(errors.Unwrap(err)); subErr != nil {
+				return nil, wrapError(subErr)
+			}
+			return nil, wrapError(err)
+		}
+		sha256 := resp["sha256"].(string)
+
+		if opts.goSums != nil {
+			if vcsOfFetcher(fetcher) != "git" {
+				logf("Not verifying %s, only git checkouts can be verified against go.sum", goPackagePath)
+			} else if storePath, _ := resp["path"].(string); storePath == "" {
+				return nil, wrapError(fmt.Errorf("nix-prefetch-git reported no path to verify against go.sum"))
+			} else if err := verifyGoSum(opts.goSums, storePath, repoRootPath, modulePath, entry.version); err != nil {
+				return nil, wrapError(err)
+			}
+		}
+
+		rev := fetchRev
+		if fetcher == fetcherFetchTree {
+			// fetchTree only accepts full commit hashes
+			rev, _ = resp["rev"].(string)
+		}
+
+		date, _ := resp["date"].(string)
+		// Only nix-prefetch-git reports the commit it fetched
+		commit := ""
+		if vcsOfFetcher(fetcher) == "git" && fetcher != fetcherGitHub {
+			commit, _ = resp["rev"].(string)
+		}
+
+		opts.hashCache.put(cacheFetcher, repoURL, entry.rev, &hashCacheEntry{
+			Rev:     rev,
+			Sha256:  sha256,
+			Commit:  commit,
+			Date:    date,
+			Fetched: time.Now(),
+		})
+
+		return &Package{
+			GoPackagePath:  goPackagePath,
+			URL:            repoURL,
+			Rev:            rev,
+			Sha256:         sha256,
+			Fetcher:        fetcher,
+			FetchLFS:       lfs,
+			LeaveDotGit:    dotGit,
+			DeepClone:      deep,
+			NoSubmodules:   noSubs,
+			BranchName:     branch,
+			SparseCheckout: sparse,
+			Commit:         opts.commitOf(commit),
+			Date:           date,
+			FetchURL:       mirroredURL(fetchURL, repoURL),
+		}, nil
+	}
+
+	// A panic must not take down the worker without a result, the results
+	// loop below would wait for it forever.
+	recoverEntry := func(entry *modEntry) (pkg *Package, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("Error processing import path \"%s\": panic: %v", entry.importPath, r)
+			}
+		}()
+		return processEntry(entry)
+	}
+
+	worker := func(entries <-chan *modEntry, results chan<- *PackageResult) {
+		for entry := range entries {
+			pkg, err := recoverEntry(entry)
+			if pkg != nil {
+				// Hashes reused from deps.nix do not know their module
+				withModule := *pkg
+				withModule.ModulePath = entry.importPath
+				withModule.Version = entry.version
+				pkg = &withModule
+			}
+			result := &PackageResult{
+				ImportPath: entry.importPath,
+				Package:    pkg,
+				Error:      err,
+			}
+			results <- result
+		}
+	}
+
+	pkgsMap := make(map[string]*Package)
+	if opts.onlyFailed {
+		entries = keepPrevPackages(entries, prevDeps, pkgsMap)
+		logf("Keeping %d modules, fetching %d missing ones", len(pkgsMap), len(entries))
+	}
+	if len(opts.only) > 0 {
+		only := make(map[string]bool)
+		for _, path := range opts.only {
+			only[path] = true
+		}
+		var fetch, rest []*modEntry
+		for _, entry := range entries {
+			if only[entry.importPath] {
+				fetch = append(fetch, entry)
+			} else {
+				rest = append(rest, entry)
+			}
+		}
+		for _, entry := range keepPrevPackages(rest, prevDeps, pkgsMap) {
+			logf("Leaving out %s, it has no entry in the input file", entry.importPath)
+		}
+		logf("Keeping %d modules, fetching %d", len(pkgsMap), len(fetch))
+		entries = fetch
+	}
+	// Fail once rather than for every module if the prefetcher is missing
+	if !opts.dryRun && opts.prefetcher == nil && len(entries) > 0 {
+		var tools []string
+		for _, tool := range prefetchTools(opts.fetcher) {
+			tools = append(tools, opts.prefetchTool(tool))
+		}
+		if err := checkPrefetchTools(tools); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	jobs := make(chan *modEntry, len(entries))
+	results := make(chan *PackageResult, len(entries))
+	for w := 1; w <= int(math.Min(float64(len(entries)), float64(opts.numJobs))); w++ {
+		go worker(jobs, results)
+	}
+
+	for _, entry := range entries {
+		jobs <- entry
+	}
+	close(jobs)
+
+	var failed []*PackageResult
+	var fallbacks []*Package
+	for j := 1; j <= len(entries); j++ {
+		var result *PackageResult
+		select {
+		case result = <-results:
+		case <-ctx.Done():
+			return sortPackages(pkgsMap), failed, ctx.Err()
+		}
+		if opts.onResult != nil {
+			opts.onResult(result, j, len(entries))
+		}
+		if result.Error != nil {
+			// Fetches killed by the deadline are not actual failures
+			if ctx.Err() != nil {
+				return sortPackages(pkgsMap), failed, ctx.Err()
+			}
+			var wouldFetch *wouldFetchError
+			if errors.As(result.Error, &wouldFetch) {
+				failed = append(failed, result)
+				continue
+			}
+			if !opts.keepGoing {
+				return nil, nil, result.Error
+			}
+			logEventf(&logEvent{Event: "error", Path: result.ImportPath, Message: result.Error.Error()}, "Encountered error: %v", result.Error)
+			failed = append(failed, result)
+			// Keep the output stable across flaky runs rather than dropping the module
+			if prevPkg := lookupPrevDep(prevDeps, result.ImportPath); prevPkg != nil {
+				if _, ok := pkgsMap[prevPkg.GoPackagePath]; !ok {
+					pkg := *prevPkg
+					pkg.ModulePath = result.ImportPath
+					pkgsMap[pkg.GoPackagePath] = &pkg
+					fallbacks = append(fallbacks, &pkg)
+				}
+			}
+			continue
+		}
+		pkgsMap[result.Package.GoPackagePath] = result.Package
+	}
+
+	if len(fallbacks) > 0 {
+		logf("Kept the previous entries of %d failed modules:", len(fallbacks))
+		for _, pkg := range sortPackages(packagesByPath(fallbacks)) {
+			logf("  %s %s", pkg.GoPackagePath, pkg.Rev)
+		}
+	}
+
+	return sortPackages(pkgsMap), failed, nil
+}
+
+func packagesByPath(packages []*Package) map[string]*Package {
+	pkgsMap := make(map[string]*Package)
+	for _, pkg := range packages {
+		pkgsMap[pkg.GoPackagePath] = pkg
+	}
+	return pkgsMap
+}
+
+// Make output order stable
+func sortPackages(pkgsMap map[string]*Package) []*Package {
+	var packages []*Package
+
+	keys := make([]string, 0, len(pkgsMap))
+	for k := range pkgsMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		packages = append(packages, pkgsMap[k])
+	}
+
+	return packages
+}
+
+// Values of --sort
+const (
+	sortPath = "path"
+	sortURL  = "url"
+)
+
+// sortPackagesByURL groups packages by their repository, with ties broken by
+// goPackagePath so the order stays stable.
+func sortPackagesByURL(packages []*Package) []*Package {
+	sorted := append([]*Package(nil), packages...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].URL != sorted[j].URL {
+			return sorted[i].URL < sorted[j].URL
+		}
+		return sorted[i].GoPackagePath < sorted[j].GoPackagePath
+	})
+	return sorted
+}
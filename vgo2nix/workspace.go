@@ -0,0 +1,34 @@
+package vgo2nix
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// goWorkFile returns the go.work file the go command picks up in dir, or ""
+// if it lists the module in it alone.
+func goWorkFile(ctx context.Context, dir string, goBinary string, goEnv []string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, goBinary, "env", "GOWORK")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"GO111MODULE=on",
+	)
+	cmd.Env = append(cmd.Env, goEnv...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("'go env GOWORK' failed with %s:\n%s", err, stderr.String())
+	}
+
+	// Toolchains older than go 1.18 know no workspaces and print nothing
+	workFile := strings.TrimSpace(string(out))
+	if workFile == "off" {
+		return "", nil
+	}
+	return workFile, nil
+}